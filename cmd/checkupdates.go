@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var checkUpdatesCmd = &cobra.Command{
+	Use:   "check-updates [stack...]",
+	Short: "Report stacks with newer images available",
+	Long: `Pull the images of all Docker Compose stacks or only the given stacks and
+report the services whose containers run an older image. No container is
+recreated; run update to apply the new images.
+
+` + stackArgsHelp,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(_ *cobra.Command, args []string) error {
+		return RunAction("check-updates", args)
+	},
+}
+
+func init() {
+	addLockFlags(checkUpdatesCmd)
+	addSelectionFlags(checkUpdatesCmd)
+	rootCmd.AddCommand(checkUpdatesCmd)
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// composeArgs holds the docker compose arguments of the compose command
+var composeArgs []string
+
+var composeCmd = &cobra.Command{
+	Use:   "compose <stack> -- <compose args...>",
+	Short: "Run a docker compose command for a stack",
+	Long: `Run an arbitrary docker compose command, such as exec, pull, config, top
+or images, for a single stack. The command gets the same project directory,
+project name and common arguments composectl uses for the stack.
+
+Arguments after -- are passed to docker compose unchanged:
+
+  composectl compose nextcloud -- exec app occ status
+  composectl compose traefik -- pull`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		stackArgs := args
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			stackArgs = args[:dash]
+		} else if len(args) > 0 {
+			stackArgs = args[:1]
+		}
+		if len(stackArgs) != 1 {
+			return fmt.Errorf("expected exactly one stack before --, got %d", len(stackArgs))
+		}
+		if len(args) < 2 {
+			return fmt.Errorf("missing docker compose command")
+		}
+		return nil
+	},
+	RunE: func(_ *cobra.Command, args []string) error {
+		composeArgs = args[1:]
+		return RunAction("compose", args[:1])
+	},
+}
+
+func init() {
+	addLockFlags(composeCmd)
+	rootCmd.AddCommand(composeCmd)
+}
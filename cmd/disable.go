@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var disableCmd = &cobra.Command{
+	Use:   "disable <stack...>",
+	Short: "Disable Docker Compose stacks",
+	Long: `Disable stacks so that start and restart skip them unless they are
+named explicitly. The state is kept in a .disabled file in the stack
+directory; running containers are not stopped.
+
+` + stackArgsHelp,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		return RunAction("disable", args)
+	},
+}
+
+func init() {
+	addLockFlags(disableCmd)
+	addSelectionFlags(disableCmd)
+	rootCmd.AddCommand(disableCmd)
+}
@@ -5,16 +5,20 @@ import (
 )
 
 var downCmd = &cobra.Command{
-	Use:   "down [stack-name]",
+	Use:   "down [stack...]",
 	Short: "Take down Docker Compose stacks",
-	Long: `Take down all Docker Compose stacks or a specific stack if provided. 
-This removes containers, networks, and volumes.`,
-	Args: cobra.MaximumNArgs(1),
+	Long: `Take down all Docker Compose stacks or only the given stacks.
+This removes containers, networks, and volumes.
+
+` + stackArgsHelp,
+	Args: cobra.ArbitraryArgs,
 	RunE: func(_ *cobra.Command, args []string) error {
 		return RunAction("down", args)
 	},
 }
 
 func init() {
+	addExecutionFlags(downCmd)
+	addSelectionFlags(downCmd)
 	rootCmd.AddCommand(downCmd)
 }
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var enableCmd = &cobra.Command{
+	Use:   "enable <stack...>",
+	Short: "Enable Docker Compose stacks",
+	Long: `Enable stacks previously disabled with the disable command so that
+they are started again by start and restart without stack arguments.
+
+` + stackArgsHelp,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		return RunAction("enable", args)
+	},
+}
+
+func init() {
+	addLockFlags(enableCmd)
+	addSelectionFlags(enableCmd)
+	rootCmd.AddCommand(enableCmd)
+}
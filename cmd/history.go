@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kreigan/adm-composectl/internal/loader"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history <stack>",
+	Short: "Show recent actions of a stack",
+	Long: `Show the most recent actions performed on a stack, newest first, with
+their result, duration, the user who ran them and the composectl version.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		if IsDryRun() {
+			return fmt.Errorf("--dry-run flag is not applicable for history command")
+		}
+		if !loader.OutputFormat(outputFormat).IsValid() {
+			return fmt.Errorf("invalid output format %q (expected table, json or yaml)", outputFormat)
+		}
+		return RunAction("history", args)
+	},
+}
+
+func init() {
+	historyCmd.Flags().StringVarP(&outputFormat, "output", "o", string(loader.OutputTable),
+		"output format: table, json or yaml")
+	rootCmd.AddCommand(historyCmd)
+}
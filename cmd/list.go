@@ -5,8 +5,13 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+
+	"github.com/kreigan/adm-composectl/internal/loader"
 )
 
+// outputFormat selects the list output format
+var outputFormat string
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all discovered stacks",
@@ -16,10 +21,16 @@ var listCmd = &cobra.Command{
 		if IsDryRun() {
 			return fmt.Errorf("--dry-run flag is not applicable for list command")
 		}
+		if !loader.OutputFormat(outputFormat).IsValid() {
+			return fmt.Errorf("invalid output format %q (expected table, wide, json or yaml)", outputFormat)
+		}
 		return RunAction("list", nil)
 	},
 }
 
 func init() {
+	listCmd.Flags().StringVarP(&outputFormat, "output", "o", string(loader.OutputTable),
+		"output format: table, wide, json or yaml")
+	addSelectionFlags(listCmd)
 	rootCmd.AddCommand(listCmd)
 }
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/kreigan/adm-composectl/internal/loader"
+)
+
+// logOptions holds the flags of the logs command
+var logOptions loader.LogOptions
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [stack...]",
+	Short: "Show container logs of stacks",
+	Long: `Show the container logs of all Docker Compose stacks or only the given
+stacks. When several stacks are selected, their logs are shown together
+with each line prefixed by the stack name.
+
+` + stackArgsHelp,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(_ *cobra.Command, args []string) error {
+		return RunAction("logs", args)
+	},
+}
+
+func init() {
+	logsCmd.Flags().BoolVarP(&logOptions.Follow, "follow", "f", false, "follow log output")
+	logsCmd.Flags().StringVar(&logOptions.Since, "since", "",
+		"show logs since a timestamp (e.g. 2024-01-02T13:23:37Z) or relative time (e.g. 42m)")
+	logsCmd.Flags().StringVarP(&logOptions.Tail, "tail", "n", "",
+		"number of lines to show from the end of the logs of each container")
+	logsCmd.Flags().StringSliceVar(&logOptions.Services, "service", nil, "only show logs of the given services")
+	addSelectionFlags(logsCmd)
+	rootCmd.AddCommand(logsCmd)
+}
@@ -5,16 +5,18 @@ import (
 )
 
 var restartCmd = &cobra.Command{
-	Use:     "restart [stack-name]",
+	Use:     "restart [stack...]",
 	Aliases: []string{"reload"},
 	Short:   "Restart Docker Compose stacks",
-	Long:    `Restart all Docker Compose stacks or a specific stack if provided.`,
-	Args:    cobra.MaximumNArgs(1),
+	Long:    "Restart all Docker Compose stacks or only the given stacks.\n\n" + stackArgsHelp,
+	Args:    cobra.ArbitraryArgs,
 	RunE: func(_ *cobra.Command, args []string) error {
 		return RunAction("restart", args)
 	},
 }
 
 func init() {
+	addExecutionFlags(restartCmd)
+	addSelectionFlags(restartCmd)
 	rootCmd.AddCommand(restartCmd)
 }
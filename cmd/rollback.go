@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <stack...>",
+	Short: "Recreate stacks with the images they ran before the last update",
+	Long: `Point the image references of the given stacks back at the images their
+services ran before the last update and recreate the stacks without pulling.
+The previous images are recorded by update in .composectl/images.json in the
+base directory.
+
+` + stackArgsHelp,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		return RunAction("rollback", args)
+	},
+}
+
+func init() {
+	addExecutionFlags(rollbackCmd)
+	addSelectionFlags(rollbackCmd)
+	rootCmd.AddCommand(rollbackCmd)
+}
@@ -7,6 +7,8 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+
+	"github.com/kreigan/adm-composectl/internal/loader"
 )
 
 var (
@@ -117,3 +119,24 @@ func IsDryRun() bool {
 func GetLogFile() string {
 	return filepath.Join(GetBaseDir(), "docker-loader.log")
 }
+
+// newLogger opens the log file with the rotation and format of config. Its
+// entries record action.
+func newLogger(config *loader.Config, action string) (*loader.Logger, error) {
+	logger, err := loader.NewLogger(GetLogFile(), IsVerbose())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	err = logger.SetRotation(config.LogRotation)
+	if err == nil {
+		err = logger.SetFormat(config.LogFormat)
+	}
+	if err != nil {
+		//nolint:errcheck // Initialization error takes precedence
+		logger.Close()
+		return nil, fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	return logger.WithAction(action), nil
+}
@@ -1,12 +1,38 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
 
 	"github.com/kreigan/adm-composectl/internal/loader"
 )
 
+var (
+	// parallel overrides the configured parallelism when set
+	parallel int
+	// keepGoing enables keep-going mode regardless of the configuration
+	keepGoing bool
+	// excludeStacks lists stack patterns to leave out of the selection
+	excludeStacks []string
+	// tagExpr restricts the selection to stacks matching a tag expression
+	tagExpr string
+	// waitLock waits for other composectl runs instead of failing
+	waitLock bool
+	// lockTimeout limits how long to wait for other composectl runs
+	lockTimeout time.Duration
+)
+
+// stackArgsHelp describes stack arguments in command help.
+const stackArgsHelp = `Stacks can be given by name or directory name, as glob patterns such as
+'media-*', or as inclusive order prefix ranges such as '10..30'.`
+
 // ActionRunner handles the common execution flow for stack actions.
 type ActionRunner struct {
 	action string
@@ -17,11 +43,16 @@ func NewActionRunner(action string) *ActionRunner {
 	return &ActionRunner{action: action}
 }
 
-// Run executes the action with the given target stack.
-func (r *ActionRunner) Run(targetStack string) error {
-	logger, err := loader.NewLogger(GetLogFile(), IsVerbose())
+// Run executes the action on the stacks matched by selector.
+func (r *ActionRunner) Run(selector loader.StackSelector) error {
+	config, err := loader.LoadConfig(GetBaseDir())
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	logger, err := newLogger(config, r.action)
 	if err != nil {
-		return fmt.Errorf("failed to initialize logger: %w", err)
+		return err
 	}
 	defer func() {
 		if err := logger.Close(); err != nil {
@@ -32,14 +63,43 @@ func (r *ActionRunner) Run(targetStack string) error {
 	logger.Info("Docker Loader started - action: %s", r.action)
 	logger.Info("Base directory: %s", GetBaseDir())
 
-	config, err := loader.LoadConfig(GetBaseDir())
+	applyExecutionFlags(config)
+
+	manager := loader.NewStackManager(GetBaseDir(), config, logger, IsDryRun())
+	manager.SetVersion(version)
+	if outputFormat != "" {
+		manager.SetOutputFormat(loader.OutputFormat(outputFormat))
+	}
+	manager.SetLogOptions(logOptions)
+	manager.SetComposeArgs(composeArgs)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Until the action starts, including while waiting for the lock, a
+	// signal aborts the run
+	setupCtx, stopSetupSignals := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stopSetupSignals()
+
+	stacks, err := manager.ResolveStacks(setupCtx, selector)
 	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+		return fmt.Errorf("%s action failed: %w", r.action, err)
 	}
 
-	manager := loader.NewStackManager(GetBaseDir(), config, logger, IsDryRun())
+	release, err := r.lock(setupCtx, selector, stacks, logger)
+	if err != nil {
+		return fmt.Errorf("%s action failed: %w", r.action, err)
+	}
+	defer release()
+
+	if loader.Action(r.action).ModifiesStacks() {
+		defer handleInterrupts(manager, cancel, logger)()
+		stopSetupSignals()
+	} else {
+		ctx = setupCtx
+	}
 
-	if err := manager.ExecuteAction(r.action, targetStack); err != nil {
+	if err := r.execute(ctx, manager, stacks, config, logger); err != nil {
 		return fmt.Errorf("%s action failed: %w", r.action, err)
 	}
 
@@ -47,12 +107,143 @@ func (r *ActionRunner) Run(targetStack string) error {
 	return nil
 }
 
-// RunAction is a convenience function for executing an action.
+// lock acquires the run lock for actions that take it: all stacks for
+// an empty selector, otherwise only the selected stacks. It returns a function
+// releasing the lock.
+func (r *ActionRunner) lock(
+	ctx context.Context, selector loader.StackSelector, stacks []*loader.Stack, logger *loader.Logger,
+) (func(), error) {
+	if !loader.Action(r.action).TakesLock() || IsDryRun() || (!selector.IsEmpty() && len(stacks) == 0) {
+		return func() {}, nil
+	}
+
+	var names []string
+	if !selector.IsEmpty() {
+		for _, stack := range stacks {
+			names = append(names, stack.Name)
+		}
+	}
+
+	opts := loader.LockOptions{Wait: waitLock || lockTimeout > 0, Timeout: lockTimeout}
+	lock, err := loader.AcquireRunLock(ctx, GetBaseDir(), r.action, names, opts, logger)
+	if err != nil {
+		var lockedErr *loader.LockedError
+		if errors.As(err, &lockedErr) && !opts.Wait {
+			return nil, fmt.Errorf("%w (use --wait or --lock-timeout to wait for it)", err)
+		}
+		return nil, err
+	}
+	return lock.Release, nil
+}
+
+// handleInterrupts makes the first SIGINT or SIGTERM stop the action once the
+// stacks in progress have finished, and a second one call cancel, killing the
+// running commands. It returns a function that restores the default handling.
+func handleInterrupts(manager *loader.StackManager, cancel context.CancelFunc, logger *loader.Logger) func() {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case sig := <-signals:
+			logger.Console("==> Received %s, stopping after the current stack (repeat to abort)", sig)
+			manager.Interrupt()
+		case <-done:
+			return
+		}
+
+		select {
+		case sig := <-signals:
+			logger.Console("==> Received %s again, aborting", sig)
+			cancel()
+		case <-done:
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// execute performs the action, surrounded by the global before-all and
+// after-all hooks for actions that modify stacks. The after-all hooks run even
+// if the action fails or is aborted.
+func (r *ActionRunner) execute(
+	ctx context.Context, manager *loader.StackManager, stacks []*loader.Stack, config *loader.Config,
+	logger *loader.Logger,
+) error {
+	if !loader.Action(r.action).ModifiesStacks() {
+		return manager.PerformAction(ctx, r.action, stacks)
+	}
+
+	hooks := loader.NewHookRunner(logger, IsDryRun())
+	event := loader.NewRunEvent(r.action, stacks)
+
+	if err := hooks.RunGlobal(ctx, "before-all", config.Hooks.BeforeAll, GetBaseDir(), event); err != nil {
+		return err
+	}
+
+	actionErr := manager.PerformAction(ctx, r.action, stacks)
+	event.Complete(manager.Results(), actionErr)
+
+	// After-all hooks also run when the action was aborted
+	afterCtx := context.WithoutCancel(ctx)
+	if err := hooks.RunGlobal(afterCtx, "after-all", config.Hooks.AfterAll, GetBaseDir(), event); err != nil {
+		if actionErr != nil {
+			logger.Error("%v", err)
+			return actionErr
+		}
+		return err
+	}
+
+	return actionErr
+}
+
+// RunAction is a convenience function for executing an action on the stacks
+// selected by args and the --exclude and --tag flags.
 func RunAction(action string, args []string) error {
-	targetStack := ""
-	if len(args) > 0 {
-		targetStack = args[0]
+	selector := loader.StackSelector{
+		Tags:    tagExpr,
+		Include: args,
+		Exclude: excludeStacks,
 	}
 
-	return NewActionRunner(action).Run(targetStack)
+	return NewActionRunner(action).Run(selector)
+}
+
+// addExecutionFlags registers flags shared by commands that operate on stacks.
+func addExecutionFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&parallel, "parallel", 0,
+		"number of stacks sharing an order prefix to process concurrently (default from config)")
+	cmd.Flags().BoolVar(&keepGoing, "keep-going", false,
+		"attempt every stack even if some fail, then print a summary")
+	addLockFlags(cmd)
+}
+
+// addLockFlags registers flags controlling how to wait for the run lock.
+func addLockFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&waitLock, "wait", false,
+		"wait for other composectl runs on the same stacks instead of failing")
+	cmd.Flags().DurationVar(&lockTimeout, "lock-timeout", 0,
+		"wait at most this long for other composectl runs on the same stacks, e.g. 5m (implies --wait)")
+}
+
+// addSelectionFlags registers flags refining the stacks selected by arguments.
+func addSelectionFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&excludeStacks, "exclude", nil,
+		"stacks to exclude (names, glob patterns or prefix ranges)")
+	cmd.Flags().StringVar(&tagExpr, "tag", "",
+		"only stacks whose tags match the expression, e.g. 'media', '!critical', 'media && !critical'")
+}
+
+// applyExecutionFlags overrides configuration values with command-line flags.
+func applyExecutionFlags(config *loader.Config) {
+	if parallel > 0 {
+		config.Parallelism = parallel
+	}
+	if keepGoing {
+		config.KeepGoing = true
+	}
 }
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kreigan/adm-composectl/internal/loader"
+)
+
+var schedulerCmd = &cobra.Command{
+	Use:   "scheduler",
+	Short: "Run scheduled stack updates",
+	Long: `Run in the foreground and update stacks whenever the schedule configured
+in config.yaml matches. Each run pulls images and recreates running stacks
+whose images changed, in start order. Stacks with auto-update: false are
+left out, and no stack is updated after the maintenance window has closed.
+
+The scheduler stops on SIGINT or SIGTERM, after the stack being updated;
+a second signal aborts the update in progress.`,
+	Args: cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return runScheduler()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schedulerCmd)
+}
+
+func runScheduler() error {
+	config, err := loader.LoadConfig(GetBaseDir())
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	logger, err := newLogger(config, "scheduler")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := logger.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close logger: %v\n", err)
+		}
+	}()
+
+	logger.Info("Docker Loader started - action: scheduler")
+	logger.Info("Base directory: %s", GetBaseDir())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	manager := loader.NewStackManager(GetBaseDir(), config, logger, IsDryRun())
+	manager.SetVersion(version)
+	defer handleInterrupts(manager, cancel, logger)()
+	if err := manager.RunScheduler(ctx); err != nil {
+		return fmt.Errorf("scheduler failed: %w", err)
+	}
+	return nil
+}
@@ -5,15 +5,17 @@ import (
 )
 
 var startCmd = &cobra.Command{
-	Use:   "start [stack-name]",
+	Use:   "start [stack...]",
 	Short: "Start Docker Compose stacks",
-	Long:  `Start all Docker Compose stacks or a specific stack if provided.`,
-	Args:  cobra.MaximumNArgs(1),
+	Long:  "Start all Docker Compose stacks or only the given stacks.\n\n" + stackArgsHelp,
+	Args:  cobra.ArbitraryArgs,
 	RunE: func(_ *cobra.Command, args []string) error {
 		return RunAction("start", args)
 	},
 }
 
 func init() {
+	addExecutionFlags(startCmd)
+	addSelectionFlags(startCmd)
 	rootCmd.AddCommand(startCmd)
 }
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kreigan/adm-composectl/internal/loader"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status [stack...]",
+	Short: "Show per-service status of stacks",
+	Long: `Show the state, health, exit code, uptime and image of every service
+of all Docker Compose stacks or only the given stacks.
+
+` + stackArgsHelp,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(_ *cobra.Command, args []string) error {
+		if IsDryRun() {
+			return fmt.Errorf("--dry-run flag is not applicable for status command")
+		}
+		if !loader.OutputFormat(outputFormat).IsValid() {
+			return fmt.Errorf("invalid output format %q (expected table, json or yaml)", outputFormat)
+		}
+		return RunAction("status", args)
+	},
+}
+
+func init() {
+	statusCmd.Flags().StringVarP(&outputFormat, "output", "o", string(loader.OutputTable),
+		"output format: table, json or yaml")
+	addSelectionFlags(statusCmd)
+	rootCmd.AddCommand(statusCmd)
+}
@@ -5,15 +5,17 @@ import (
 )
 
 var stopCmd = &cobra.Command{
-	Use:   "stop [stack-name]",
+	Use:   "stop [stack...]",
 	Short: "Stop Docker Compose stacks",
-	Long:  `Stop all Docker Compose stacks or a specific stack if provided.`,
-	Args:  cobra.MaximumNArgs(1),
+	Long:  "Stop all Docker Compose stacks or only the given stacks.\n\n" + stackArgsHelp,
+	Args:  cobra.ArbitraryArgs,
 	RunE: func(_ *cobra.Command, args []string) error {
 		return RunAction("stop", args)
 	},
 }
 
 func init() {
+	addExecutionFlags(stopCmd)
+	addSelectionFlags(stopCmd)
 	rootCmd.AddCommand(stopCmd)
 }
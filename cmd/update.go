@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var updateCmd = &cobra.Command{
+	Use:   "update [stack...]",
+	Short: "Pull new images and recreate changed stacks",
+	Long: `Pull the images of all Docker Compose stacks or only the given stacks and
+recreate running stacks whose containers run an older image. Stacks that
+are up to date or not running are left untouched.
+
+` + stackArgsHelp,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(_ *cobra.Command, args []string) error {
+		return RunAction("update", args)
+	},
+}
+
+func init() {
+	addExecutionFlags(updateCmd)
+	addSelectionFlags(updateCmd)
+	rootCmd.AddCommand(updateCmd)
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kreigan/adm-composectl/internal/loader"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [stack...]",
+	Short: "Check configuration and compose files",
+	Long: `Check the global config.yaml and the config.yaml and compose file of all
+stacks or only the given stacks. Configuration files are parsed strictly,
+so unknown keys are reported, and each compose file is checked with
+docker compose config using the same arguments as other commands.
+
+All issues are listed and the command exits non-zero if any are found.
+
+` + stackArgsHelp,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(_ *cobra.Command, args []string) error {
+		return runValidate(loader.StackSelector{Tags: tagExpr, Include: args, Exclude: excludeStacks})
+	},
+}
+
+func init() {
+	addSelectionFlags(validateCmd)
+	rootCmd.AddCommand(validateCmd)
+}
+
+func runValidate(selector loader.StackSelector) error {
+	// Validate reports the problems of config.yaml itself, so the stacks are
+	// still checked, with the default configuration, if it cannot be loaded
+	config, err := loader.LoadConfig(GetBaseDir())
+	if err != nil {
+		config = loader.DefaultConfig(GetBaseDir())
+	}
+
+	// An invalid log setting is one of the issues to report, so the default
+	// settings are used instead
+	logger, err := newLogger(config, "validate")
+	if err != nil {
+		if logger, err = newLogger(loader.DefaultConfig(GetBaseDir()), "validate"); err != nil {
+			return err
+		}
+	}
+	defer func() {
+		if err := logger.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close logger: %v\n", err)
+		}
+	}()
+
+	logger.Info("Docker Loader started - action: validate")
+	logger.Info("Base directory: %s", GetBaseDir())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	manager := loader.NewStackManager(GetBaseDir(), config, logger, IsDryRun())
+	if err := manager.Validate(ctx, selector); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/kreigan/adm-composectl/internal/loader"
+)
+
+// newValidateBaseDir creates a base directory with the given global
+// config.yaml and a stack without a compose file, and makes it the base
+// directory of the commands.
+func newValidateBaseDir(t *testing.T, config string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "stacks", "01-web"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Set("base_dir", dir)
+	t.Cleanup(func() { viper.Set("base_dir", "") })
+}
+
+func TestRunValidate(t *testing.T) {
+	t.Run("reports a global config type error and checks the stacks", func(t *testing.T) {
+		newValidateBaseDir(t, "timeout: abc\n")
+
+		err := runValidate(loader.StackSelector{})
+		var validationErr *loader.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("Expected ValidationError, got %v", err)
+		}
+
+		issues := strings.Join(validationErr.Issues, "\n")
+		for _, want := range []string{"config.yaml: line 1: cannot unmarshal", "stack web: no compose file"} {
+			if !strings.Contains(issues, want) {
+				t.Errorf("Expected issue %q, got:\n%s", want, issues)
+			}
+		}
+	})
+
+	t.Run("reports an invalid log format once", func(t *testing.T) {
+		newValidateBaseDir(t, "log-format: xml\n")
+
+		err := runValidate(loader.StackSelector{})
+		var validationErr *loader.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("Expected ValidationError, got %v", err)
+		}
+
+		issues := strings.Join(validationErr.Issues, "\n")
+		if count := strings.Count(issues, `invalid log-format "xml"`); count != 1 {
+			t.Errorf("Expected the log format reported once, got:\n%s", issues)
+		}
+	})
+}
@@ -2,9 +2,16 @@
 package loader
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -14,20 +21,266 @@ type Config struct {
 	CommonArgs []string `yaml:"common-args"`
 	UpArgs     []string `yaml:"up-args"`
 	DownArgs   []string `yaml:"down-args"`
-	Timeout    int      `yaml:"timeout"`
+	// EnvFiles are passed to docker compose as --env-file
+	EnvFiles []string `yaml:"env-files"`
+	// Profiles are passed to docker compose as --profile
+	Profiles []string `yaml:"profiles"`
+	// ComposeFiles are passed to docker compose as --file, replacing the
+	// default compose file lookup in the stack directory
+	ComposeFiles []string `yaml:"compose-files"`
+	// QueryBackend selects how containers and images are queried: "cli"
+	// (default) or "api" for the Docker Engine API
+	QueryBackend string `yaml:"query-backend"`
+	// DockerSocket is the Engine API socket used by the api query backend
+	DockerSocket string `yaml:"docker-socket"`
+	Timeout      int    `yaml:"timeout"`
+	// OperationTimeout limits each docker compose command that changes
+	// containers, in seconds. Zero means no limit.
+	OperationTimeout int         `yaml:"operation-timeout"`
+	Hooks            GlobalHooks `yaml:"hooks"`
+	Schedule         Schedule    `yaml:"schedule"`
+	LogRotation      LogRotation `yaml:"log-rotation"`
+	// LogFormat is the format of the log file: "text" (default) or "json"
+	LogFormat   LogFormat `yaml:"log-format"`
+	Parallelism int       `yaml:"parallelism"`
+	KeepGoing   bool      `yaml:"keep-going"`
+}
+
+// LogRotation configures rotation of the log file. Rotated logs are
+// compressed with gzip. Rotation is disabled unless MaxSize or MaxAge is set.
+type LogRotation struct {
+	// MaxSize rotates the log before it grows beyond this many megabytes
+	MaxSize int `yaml:"max-size"`
+	// MaxAge rotates the log once it was last rotated this many days ago
+	MaxAge int `yaml:"max-age"`
+	// MaxFiles is the number of rotated logs kept, 5 if unset
+	MaxFiles int `yaml:"max-files"`
+}
+
+// defaultLogMaxFiles is used when rotation is enabled without max-files.
+const defaultLogMaxFiles = 5
+
+// Enabled reports whether the log is rotated.
+func (r *LogRotation) Enabled() bool {
+	return r.MaxSize > 0 || r.MaxAge > 0
+}
+
+// MaxBytes returns the size at which the log is rotated, or zero if unlimited.
+func (r *LogRotation) MaxBytes() int64 {
+	return int64(max(r.MaxSize, 0)) << 20
+}
+
+// MaxAgeDuration returns the time between rotations, or zero if unlimited.
+func (r *LogRotation) MaxAgeDuration() time.Duration {
+	return time.Duration(max(r.MaxAge, 0)) * 24 * time.Hour
+}
+
+// RetainedFiles returns the number of rotated logs kept.
+func (r *LogRotation) RetainedFiles() int {
+	if r.MaxFiles <= 0 {
+		return defaultLogMaxFiles
+	}
+	return r.MaxFiles
+}
+
+// Schedule configures automatic updates run by the scheduler.
+type Schedule struct {
+	// Cron is the cron expression at which scheduled updates start
+	Cron string `yaml:"cron"`
+	// Window limits how long after its start an update may begin updating
+	// stacks, e.g. "2h". Empty means no limit.
+	Window string `yaml:"window"`
+}
+
+// WindowDuration returns the length of the maintenance window, or zero if unlimited.
+func (s *Schedule) WindowDuration() (time.Duration, error) {
+	if s.Window == "" {
+		return 0, nil
+	}
+	window, err := time.ParseDuration(s.Window)
+	if err != nil || window < 0 {
+		return 0, fmt.Errorf("invalid schedule window %q", s.Window)
+	}
+	return window, nil
+}
+
+// OperationTimeoutDuration returns the time limit of docker commands that
+// change containers, or zero if unlimited.
+func (c *Config) OperationTimeoutDuration() time.Duration {
+	return time.Duration(c.OperationTimeout) * time.Second
+}
+
+// GlobalHooks lists commands run around whole runs of stack-modifying actions.
+type GlobalHooks struct {
+	BeforeAll []Hook `yaml:"before-all"`
+	AfterAll  []Hook `yaml:"after-all"`
 }
 
 // StackConfig represents per-stack configuration.
+//
+// The argument and file lists replace the global ones unless Merge sets
+// their strategy to MergeAppend.
 type StackConfig struct {
-	UpArgs   []string `yaml:"up-args"`
-	DownArgs []string `yaml:"down-args"`
+	CommonArgs   []string `yaml:"common-args"`
+	UpArgs       []string `yaml:"up-args"`
+	DownArgs     []string `yaml:"down-args"`
+	EnvFiles     []string `yaml:"env-files"`
+	Profiles     []string `yaml:"profiles"`
+	ComposeFiles []string `yaml:"compose-files"`
+	DependsOn    []string `yaml:"depends-on"`
+	Tags         []string `yaml:"tags"`
+	// Merge maps list fields, by their YAML key, to how they are combined
+	// with the global configuration
+	Merge map[string]MergeStrategy `yaml:"merge"`
+	Hooks StackHooks               `yaml:"hooks"`
+	// Timeout overrides the global stop timeout in seconds
+	Timeout       *int  `yaml:"timeout"`
+	AutoUpdate    *bool `yaml:"auto-update"`
+	HealthTimeout int   `yaml:"health-timeout"`
+	WaitHealthy   bool  `yaml:"wait-healthy"`
 }
 
-// LoadConfig loads the global configuration from the base directory.
-func LoadConfig(baseDir string) (*Config, error) {
-	configPath := filepath.Join(baseDir, "config.yaml")
+// MergeStrategy defines how a stack list field is combined with the global one.
+type MergeStrategy string
+
+const (
+	// MergeReplace uses the stack values instead of the global ones, if any
+	// are set. This is the default.
+	MergeReplace MergeStrategy = "replace"
+	// MergeAppend adds the stack values after the global ones.
+	MergeAppend MergeStrategy = "append"
+)
+
+// UnmarshalYAML rejects unknown merge strategies, so that a typo does not
+// silently replace the global values.
+func (m *MergeStrategy) UnmarshalYAML(value *yaml.Node) error {
+	var strategy string
+	if err := value.Decode(&strategy); err != nil {
+		return err
+	}
+
+	switch MergeStrategy(strategy) {
+	case MergeReplace, MergeAppend:
+		*m = MergeStrategy(strategy)
+		return nil
+	default:
+		// A type error lets decoding continue, so all problems are reported
+		return &yaml.TypeError{Errors: []string{
+			fmt.Sprintf("line %d: invalid merge strategy %q (expected replace or append)", value.Line, strategy),
+		}}
+	}
+}
+
+// mergeFields are the StackConfig keys that accept a merge strategy.
+var mergeFields = []string{"common-args", "up-args", "down-args", "env-files", "profiles", "compose-files"}
+
+// mergeIssues returns the merge keys of the stack that are not list fields.
+// Invalid strategies are already rejected when decoding.
+func (s *StackConfig) mergeIssues() []string {
+	var issues []string
+	for _, field := range slices.Sorted(maps.Keys(s.Merge)) {
+		if !slices.Contains(mergeFields, field) {
+			issues = append(issues, fmt.Sprintf("merge: unknown field %q (expected one of %s)",
+				field, strings.Join(mergeFields, ", ")))
+		}
+	}
+	return issues
+}
+
+// mergeList combines the global and stack values of a list field.
+func (s *StackConfig) mergeList(field string, global, stack []string) []string {
+	if s.Merge[field] == MergeAppend {
+		return slices.Concat(global, stack)
+	}
+	if len(stack) > 0 {
+		return stack
+	}
+	return global
+}
+
+// AutoUpdateEnabled reports whether scheduled updates include the stack.
+// Stacks opt out with auto-update: false.
+func (s *StackConfig) AutoUpdateEnabled() bool {
+	return s.AutoUpdate == nil || *s.AutoUpdate
+}
 
-	// Default configuration
+// StackHooks lists commands run around stack operations.
+// Stop hooks also run around down.
+type StackHooks struct {
+	PreStart  []Hook `yaml:"pre-start"`
+	PostStart []Hook `yaml:"post-start"`
+	PreStop   []Hook `yaml:"pre-stop"`
+	PostStop  []Hook `yaml:"post-stop"`
+}
+
+// HookFailurePolicy defines what happens when a hook fails.
+type HookFailurePolicy string
+
+const (
+	// HookFailureAbort fails the operation when the hook fails.
+	HookFailureAbort HookFailurePolicy = "abort"
+	// HookFailureWarn logs a warning and continues when the hook fails.
+	HookFailureWarn HookFailurePolicy = "warn"
+)
+
+// Hook is a shell command or script run by composectl.
+// A plain string in YAML is shorthand for a hook with only a command.
+type Hook struct {
+	Command   string            `yaml:"command"`
+	Script    string            `yaml:"script"`
+	OnFailure HookFailurePolicy `yaml:"on-failure"`
+	Timeout   int               `yaml:"timeout"`
+}
+
+// UnmarshalYAML accepts either a command string or a hook mapping, and
+// rejects unknown failure policies.
+func (h *Hook) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		h.Command = value.Value
+		return nil
+	}
+
+	type plain Hook
+	if err := value.Decode((*plain)(h)); err != nil {
+		return err
+	}
+
+	switch h.OnFailure {
+	case "", HookFailureAbort, HookFailureWarn:
+		return nil
+	default:
+		// A type error lets decoding continue, so all problems are reported
+		return &yaml.TypeError{Errors: []string{
+			fmt.Sprintf("line %d: invalid hook on-failure %q (expected abort or warn)", value.Line, h.OnFailure),
+		}}
+	}
+}
+
+// defaultHookTimeout is used for hooks without timeout.
+const defaultHookTimeout = 60 * time.Second
+
+// TimeoutDuration returns how long the hook may run.
+func (h *Hook) TimeoutDuration() time.Duration {
+	if h.Timeout <= 0 {
+		return defaultHookTimeout
+	}
+	return time.Duration(h.Timeout) * time.Second
+}
+
+// defaultHealthTimeout is used when wait-healthy is set without health-timeout.
+const defaultHealthTimeout = 60 * time.Second
+
+// HealthTimeoutDuration returns how long to wait for services to become healthy.
+func (s *StackConfig) HealthTimeoutDuration() time.Duration {
+	if s.HealthTimeout <= 0 {
+		return defaultHealthTimeout
+	}
+	return time.Duration(s.HealthTimeout) * time.Second
+}
+
+// DefaultConfig returns the global configuration used when the base
+// directory has no config.yaml.
+func DefaultConfig(baseDir string) *Config {
 	config := &Config{
 		CommonArgs: []string{},
 		UpArgs: []string{
@@ -37,8 +290,9 @@ func LoadConfig(baseDir string) (*Config, error) {
 			"--pull",
 			"always",
 		},
-		DownArgs: []string{},
-		Timeout:  10,
+		DownArgs:    []string{},
+		Timeout:     10,
+		Parallelism: 1,
 	}
 
 	// Add --env-file only if .env exists
@@ -46,6 +300,13 @@ func LoadConfig(baseDir string) (*Config, error) {
 	if _, err := os.Stat(envFile); err == nil {
 		config.CommonArgs = append(config.CommonArgs, "--env-file", envFile)
 	}
+	return config
+}
+
+// LoadConfig loads the global configuration from the base directory.
+func LoadConfig(baseDir string) (*Config, error) {
+	configPath := filepath.Join(baseDir, "config.yaml")
+	config := DefaultConfig(baseDir)
 
 	// Check if config file exists
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
@@ -59,19 +320,13 @@ func LoadConfig(baseDir string) (*Config, error) {
 	}
 
 	// Parse YAML
-	if err := yaml.Unmarshal(data, config); err != nil {
+	if err := decodeYAML(data, config, false); err != nil {
 		return nil, fmt.Errorf("parsing config file: %w", err)
 	}
 
-	// Make paths in common_args absolute
-	for i := 0; i < len(config.CommonArgs); i++ {
-		if config.CommonArgs[i] == "--env-file" && i+1 < len(config.CommonArgs) {
-			envFile := config.CommonArgs[i+1]
-			if !filepath.IsAbs(envFile) {
-				config.CommonArgs[i+1] = filepath.Join(baseDir, envFile)
-			}
-		}
-	}
+	config.CommonArgs = absEnvFileArgs(config.CommonArgs, baseDir)
+	config.EnvFiles = absPaths(config.EnvFiles, baseDir)
+	config.ComposeFiles = absPaths(config.ComposeFiles, baseDir)
 
 	return config, nil
 }
@@ -93,29 +348,65 @@ func LoadStackConfig(stackDir string) (*StackConfig, error) {
 
 	// Parse YAML
 	var stackConfig StackConfig
-	if err := yaml.Unmarshal(data, &stackConfig); err != nil {
+	if err := decodeYAML(data, &stackConfig, false); err != nil {
 		return nil, fmt.Errorf("parsing stack config: %w", err)
 	}
 
+	stackConfig.CommonArgs = absEnvFileArgs(stackConfig.CommonArgs, stackDir)
+	stackConfig.EnvFiles = absPaths(stackConfig.EnvFiles, stackDir)
+	stackConfig.ComposeFiles = absPaths(stackConfig.ComposeFiles, stackDir)
+
 	return &stackConfig, nil
 }
 
+// absEnvFileArgs makes the paths of --env-file arguments absolute relative to dir.
+func absEnvFileArgs(args []string, dir string) []string {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--env-file" && i+1 < len(args) && !filepath.IsAbs(args[i+1]) {
+			args[i+1] = filepath.Join(dir, args[i+1])
+		}
+	}
+	return args
+}
+
+// absPaths makes paths absolute relative to dir.
+func absPaths(paths []string, dir string) []string {
+	for i, path := range paths {
+		if !filepath.IsAbs(path) {
+			paths[i] = filepath.Join(dir, path)
+		}
+	}
+	return paths
+}
+
+// decodeYAML parses a YAML document into v. Strict decoding rejects keys
+// that do not map to a field of v. An empty document leaves v unchanged.
+func decodeYAML(data []byte, v any, strict bool) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(strict)
+	if err := decoder.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
 // MergeStackConfig merges stack-specific config with global config.
+// List fields are replaced or appended to according to the stack's merge
+// strategies; a stack timeout overrides the global one.
 func (c *Config) MergeStackConfig(stackConfig *StackConfig) *Config {
+	s := stackConfig
 	merged := &Config{
-		CommonArgs: c.CommonArgs,
-		UpArgs:     c.UpArgs,
-		DownArgs:   c.DownArgs,
-	}
-
-	// Override up_args if provided
-	if len(stackConfig.UpArgs) > 0 {
-		merged.UpArgs = stackConfig.UpArgs
+		CommonArgs:   s.mergeList("common-args", c.CommonArgs, s.CommonArgs),
+		UpArgs:       s.mergeList("up-args", c.UpArgs, s.UpArgs),
+		DownArgs:     s.mergeList("down-args", c.DownArgs, s.DownArgs),
+		EnvFiles:     s.mergeList("env-files", c.EnvFiles, s.EnvFiles),
+		Profiles:     s.mergeList("profiles", c.Profiles, s.Profiles),
+		ComposeFiles: s.mergeList("compose-files", c.ComposeFiles, s.ComposeFiles),
+		Timeout:      c.Timeout,
 	}
 
-	// Override down_args if provided
-	if len(stackConfig.DownArgs) > 0 {
-		merged.DownArgs = stackConfig.DownArgs
+	if s.Timeout != nil {
+		merged.Timeout = *s.Timeout
 	}
 
 	return merged
@@ -1,6 +1,11 @@
 package loader
 
-import "testing"
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestMergeStackConfig(t *testing.T) {
 	global := &Config{
@@ -30,15 +35,82 @@ func TestMergeStackConfig(t *testing.T) {
 		assertSliceEqual(t, merged.UpArgs, global.UpArgs) // unchanged
 	})
 
+	t.Run("stack config overrides common-args", func(t *testing.T) {
+		stack := &StackConfig{CommonArgs: []string{"--ansi", "never"}}
+		merged := global.MergeStackConfig(stack)
+		assertSliceEqual(t, merged.CommonArgs, []string{"--ansi", "never"})
+	})
+
+	t.Run("append strategy adds to global", func(t *testing.T) {
+		stack := &StackConfig{
+			CommonArgs: []string{"--ansi", "never"},
+			Profiles:   []string{"debug"},
+			Merge:      map[string]MergeStrategy{"common-args": MergeAppend, "profiles": MergeAppend},
+		}
+		merged := (&Config{CommonArgs: []string{"--compatibility"}, Profiles: []string{"web"}}).MergeStackConfig(stack)
+		assertSliceEqual(t, merged.CommonArgs, []string{"--compatibility", "--ansi", "never"})
+		assertSliceEqual(t, merged.Profiles, []string{"web", "debug"})
+	})
+
+	t.Run("stack timeout overrides global", func(t *testing.T) {
+		timeout := 0
+		merged := (&Config{Timeout: 10}).MergeStackConfig(&StackConfig{Timeout: &timeout})
+		if merged.Timeout != 0 {
+			t.Errorf("Expected timeout 0, got %d", merged.Timeout)
+		}
+		if merged = (&Config{Timeout: 10}).MergeStackConfig(&StackConfig{}); merged.Timeout != 10 {
+			t.Errorf("Expected global timeout 10, got %d", merged.Timeout)
+		}
+	})
+
 	t.Run("original config not modified", func(t *testing.T) {
 		original := len(global.UpArgs)
 		_ = global.MergeStackConfig(&StackConfig{UpArgs: []string{"--changed"}})
 		if len(global.UpArgs) != original {
 			t.Error("Global config was modified")
 		}
+
+		// Appending must not write into spare capacity of the global slice
+		globalArgs := make([]string, 1, 4)
+		stack := &StackConfig{
+			CommonArgs: []string{"--changed"},
+			Merge:      map[string]MergeStrategy{"common-args": MergeAppend},
+		}
+		_ = (&Config{CommonArgs: globalArgs}).MergeStackConfig(stack)
+		if spare := globalArgs[:2]; spare[1] != "" {
+			t.Error("Global config was modified")
+		}
 	})
 }
 
+func TestStackConfigMergeIssues(t *testing.T) {
+	config := &StackConfig{Merge: map[string]MergeStrategy{
+		"up-args": MergeAppend,
+		"tags":    MergeAppend,
+	}}
+
+	issues := config.mergeIssues()
+	if len(issues) != 1 {
+		t.Fatalf("Expected 1 issue, got %v", issues)
+	}
+	if !strings.Contains(issues[0], `unknown field "tags"`) {
+		t.Errorf("Unexpected issue: %s", issues[0])
+	}
+}
+
+func TestLoadStackConfigRejectsUnknownMergeStrategy(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "config.yaml", "merge:\n  env-files: apend\n")
+
+	_, err := LoadStackConfig(dir)
+	if err == nil {
+		t.Fatal("Expected error for unknown merge strategy")
+	}
+	if !strings.Contains(err.Error(), `line 2: invalid merge strategy "apend"`) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
 func TestLoadConfig(t *testing.T) {
 	t.Run("defaults without config file", func(t *testing.T) {
 		config, err := LoadConfig(t.TempDir())
@@ -109,4 +181,150 @@ func TestLoadStackConfig(t *testing.T) {
 		}
 		assertSliceEqual(t, config.UpArgs, []string{"--no-build"})
 	})
+
+	t.Run("resolves paths relative to the stack directory", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, "config.yaml", `common-args: ["--env-file", "app.env"]
+env-files: [secrets.env, /etc/shared.env]
+compose-files: [compose.yaml, compose.override.yaml]
+timeout: 30
+merge:
+  env-files: append
+`)
+
+		config, err := LoadStackConfig(dir)
+		if err != nil {
+			t.Fatalf("LoadStackConfig failed: %v", err)
+		}
+		assertSliceEqual(t, config.CommonArgs, []string{"--env-file", filepath.Join(dir, "app.env")})
+		assertSliceEqual(t, config.EnvFiles, []string{filepath.Join(dir, "secrets.env"), "/etc/shared.env"})
+		assertSliceEqual(t, config.ComposeFiles,
+			[]string{filepath.Join(dir, "compose.yaml"), filepath.Join(dir, "compose.override.yaml")})
+		if config.Timeout == nil || *config.Timeout != 30 {
+			t.Errorf("Expected timeout 30, got %v", config.Timeout)
+		}
+		if config.Merge["env-files"] != MergeAppend {
+			t.Errorf("Expected append strategy for env-files, got %q", config.Merge["env-files"])
+		}
+	})
+}
+
+func TestStackConfigHealthTimeout(t *testing.T) {
+	t.Run("defaults when unset", func(t *testing.T) {
+		config := &StackConfig{}
+		if config.HealthTimeoutDuration() != defaultHealthTimeout {
+			t.Errorf("Expected default timeout, got %s", config.HealthTimeoutDuration())
+		}
+	})
+
+	t.Run("loads from yaml file", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, "config.yaml", "wait-healthy: true\nhealth-timeout: 120\n")
+
+		config, err := LoadStackConfig(dir)
+		if err != nil {
+			t.Fatalf("LoadStackConfig failed: %v", err)
+		}
+		if !config.WaitHealthy {
+			t.Error("Expected wait-healthy to be enabled")
+		}
+		if config.HealthTimeoutDuration() != 2*time.Minute {
+			t.Errorf("Expected 2m timeout, got %s", config.HealthTimeoutDuration())
+		}
+	})
+}
+
+func TestLoadStackConfigHooks(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "config.yaml", `hooks:
+  pre-start:
+    - mount /share
+    - script: scripts/fix-perms.sh
+      timeout: 30
+      on-failure: warn
+  post-stop:
+    - command: notify stopped
+`)
+
+	config, err := LoadStackConfig(dir)
+	if err != nil {
+		t.Fatalf("LoadStackConfig failed: %v", err)
+	}
+
+	if len(config.Hooks.PreStart) != 2 {
+		t.Fatalf("Expected 2 pre-start hooks, got %d", len(config.Hooks.PreStart))
+	}
+	if config.Hooks.PreStart[0].Command != "mount /share" {
+		t.Errorf("Expected string shorthand as command, got %+v", config.Hooks.PreStart[0])
+	}
+	second := config.Hooks.PreStart[1]
+	if second.Script != "scripts/fix-perms.sh" || second.OnFailure != HookFailureWarn {
+		t.Errorf("Unexpected hook: %+v", second)
+	}
+	if second.TimeoutDuration() != 30*time.Second {
+		t.Errorf("Expected 30s timeout, got %s", second.TimeoutDuration())
+	}
+	if len(config.Hooks.PostStop) != 1 || config.Hooks.PostStop[0].Command != "notify stopped" {
+		t.Errorf("Unexpected post-stop hooks: %+v", config.Hooks.PostStop)
+	}
+}
+
+func TestLoadConfigRejectsUnknownHookFailurePolicy(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "config.yaml", "hooks:\n  pre-stop:\n    - command: backup\n      on-failure: contine\n")
+
+	_, err := LoadStackConfig(dir)
+	if err == nil || !strings.Contains(err.Error(), `invalid hook on-failure "contine"`) {
+		t.Errorf("Expected stack config error, got: %v", err)
+	}
+	writeFile(t, dir, "config.yaml", "hooks:\n  before-all:\n    - command: mount\n      on-failure: contine\n")
+	if _, err := LoadConfig(dir); err == nil {
+		t.Error("Expected global config error")
+	}
+}
+
+func TestScheduleConfig(t *testing.T) {
+	t.Run("loads schedule from yaml file", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, "config.yaml", "schedule:\n  cron: \"0 4 * * sun\"\n  window: 90m\n")
+
+		config, err := LoadConfig(dir)
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if config.Schedule.Cron != "0 4 * * sun" {
+			t.Errorf("Unexpected cron: %q", config.Schedule.Cron)
+		}
+		window, err := config.Schedule.WindowDuration()
+		if err != nil || window != 90*time.Minute {
+			t.Errorf("Expected 90m window, got %s (%v)", window, err)
+		}
+	})
+
+	t.Run("empty window is unlimited", func(t *testing.T) {
+		window, err := (&Schedule{}).WindowDuration()
+		if err != nil || window != 0 {
+			t.Errorf("Expected zero window, got %s (%v)", window, err)
+		}
+	})
+
+	t.Run("auto-update defaults to enabled", func(t *testing.T) {
+		dir := t.TempDir()
+		config, err := LoadStackConfig(dir)
+		if err != nil {
+			t.Fatalf("LoadStackConfig failed: %v", err)
+		}
+		if !config.AutoUpdateEnabled() {
+			t.Error("Expected auto-update to be enabled")
+		}
+
+		writeFile(t, dir, "config.yaml", "auto-update: false\n")
+		config, err = LoadStackConfig(dir)
+		if err != nil {
+			t.Fatalf("LoadStackConfig failed: %v", err)
+		}
+		if config.AutoUpdateEnabled() {
+			t.Error("Expected auto-update to be disabled")
+		}
+	})
 }
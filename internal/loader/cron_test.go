@@ -0,0 +1,84 @@
+package loader
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	valid := []string{
+		"* * * * *",
+		"0 4 * * 0",
+		"*/15 2-5 1,15 * mon-fri",
+		"30 3 * jan-mar,dec SUN",
+		"0 0 * * 7",
+		"5/10 * * * *",
+		"@daily",
+		"@Weekly",
+	}
+	for _, expr := range valid {
+		if _, err := ParseCron(expr); err != nil {
+			t.Errorf("ParseCron(%q) unexpected error: %v", expr, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@reboot",
+	}
+	for _, expr := range invalid {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) expected error", expr)
+		}
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	// 2024-01-03 is a Wednesday
+	from := time.Date(2024, 1, 3, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 3, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 3, 10, 30, 0, 0, time.UTC)},
+		{"0 4 * * *", time.Date(2024, 1, 4, 4, 0, 0, 0, time.UTC)},
+		{"0 4 * * sun", time.Date(2024, 1, 7, 4, 0, 0, 0, time.UTC)},
+		{"0 4 * * 7", time.Date(2024, 1, 7, 4, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// Restricted day of month and day of week match either
+		{"0 0 15 * fri", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		schedule, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q) unexpected error: %v", tt.expr, err)
+		}
+		if got := schedule.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+
+	t.Run("impossible date returns zero time", func(t *testing.T) {
+		schedule, err := ParseCron("0 0 30 2 *")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got := schedule.Next(from); !got.IsZero() {
+			t.Errorf("Expected zero time, got %s", got)
+		}
+	})
+}
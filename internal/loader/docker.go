@@ -1,35 +1,132 @@
 package loader
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
 )
 
 // DockerExecutor handles Docker command execution.
+// Commands are killed when ctx is done.
 type DockerExecutor interface {
-	Run(args []string) error
-	RunQuiet(args []string) ([]byte, error)
+	Run(ctx context.Context, args []string) error
+	RunQuiet(ctx context.Context, args []string) ([]byte, error)
 }
 
+// OutputRedirector is implemented by executors whose command output can be
+// sent to a writer other than the terminal.
+type OutputRedirector interface {
+	WithOutput(w io.Writer) DockerExecutor
+}
+
+// InteractiveRunner is implemented by executors that can run a command
+// attached to the terminal. Other commands run detached from it, so a Ctrl-C
+// on the terminal does not reach them.
+type InteractiveRunner interface {
+	// RunInteractive executes a command that may read from the terminal,
+	// such as docker compose exec, or that runs until the user interrupts
+	// it, such as docker compose logs --follow.
+	RunInteractive(ctx context.Context, args []string) error
+}
+
+// OutputLogger is implemented by executors that can copy the output of
+// commands run with Run into the log file.
+type OutputLogger interface {
+	// WithOutputLog returns an executor logging the output of a command of
+	// a stack.
+	WithOutputLog(stack, command string) DockerExecutor
+}
+
+// DockerQuerier answers the read-only queries about containers and images
+// that composectl makes for status reporting and update checks.
+type DockerQuerier interface {
+	// HasContainers reports whether a stack has any containers.
+	HasContainers(ctx context.Context, stack *Stack) (bool, error)
+	// Services returns the state of every container of a stack.
+	Services(ctx context.Context, stack *Stack) ([]ServiceState, error)
+	// Projects returns the status of every Docker Compose project by name.
+	Projects(ctx context.Context) (map[string]ProjectInfo, error)
+	// ContainerImage returns the ID of the image a container runs.
+	ContainerImage(ctx context.Context, container string) (string, error)
+	// ImageID returns the ID of the local image an image reference points to.
+	ImageID(ctx context.Context, ref string) (string, error)
+}
+
+// Query backends selectable with the query-backend setting.
+const (
+	// QueryBackendCLI runs docker CLI commands for queries.
+	QueryBackendCLI = "cli"
+	// QueryBackendAPI queries the Docker Engine API over its unix socket.
+	QueryBackendAPI = "api"
+)
+
 // DefaultDockerExecutor executes Docker commands on the host.
 type DefaultDockerExecutor struct {
 	logger *Logger
-	dryRun bool
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	// logCommand, if set, makes Run copy command output into the log file,
+	// recorded as output of logCommand of logStack
+	logCommand string
+	logStack   string
+	dryRun     bool
 }
 
 // NewDockerExecutor creates a new Docker executor.
 func NewDockerExecutor(logger *Logger, dryRun bool) *DefaultDockerExecutor {
 	return &DefaultDockerExecutor{
 		logger: logger,
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
 		dryRun: dryRun,
 	}
 }
 
-// Run executes a Docker command with TTY passthrough.
-func (e *DefaultDockerExecutor) Run(args []string) error {
+// WithOutput returns a copy of the executor that writes both stdout and stderr
+// of commands to w. The copy does not read from stdin.
+func (e *DefaultDockerExecutor) WithOutput(w io.Writer) DockerExecutor {
+	return &DefaultDockerExecutor{
+		logger: e.logger,
+		stdout: w,
+		stderr: w,
+		dryRun: e.dryRun,
+	}
+}
+
+// WithOutputLog returns a copy of the executor that also writes the output of
+// commands to the log file as output of command of stack. Output going to a
+// terminal is not copied, so that interactive runs keep their TTY.
+func (e *DefaultDockerExecutor) WithOutputLog(stack, command string) DockerExecutor {
+	executor := *e
+	executor.logStack = stack
+	executor.logCommand = command
+	return &executor
+}
+
+// Run executes a Docker command in its own process group, without stdin.
+// Its output is passed through.
+func (e *DefaultDockerExecutor) Run(ctx context.Context, args []string) error {
+	return e.run(ctx, args, false)
+}
+
+// RunInteractive executes a Docker command in the foreground process group
+// with TTY passthrough.
+func (e *DefaultDockerExecutor) RunInteractive(ctx context.Context, args []string) error {
+	return e.run(ctx, args, true)
+}
+
+func (e *DefaultDockerExecutor) run(ctx context.Context, args []string, interactive bool) error {
 	if e.dryRun {
 		e.logger.Info("[DRY-RUN] Would execute: docker %s", strings.Join(args, " "))
 		return nil
@@ -37,77 +134,386 @@ func (e *DefaultDockerExecutor) Run(args []string) error {
 
 	e.logger.Debug("Executing: docker %s", strings.Join(args, " "))
 
-	cmd := exec.Command("docker", args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd := dockerCommand(ctx, args, !interactive)
+	if interactive {
+		cmd.Stdin = e.stdin
+	}
+	cmd.Stdout = e.tee(e.stdout)
+	cmd.Stderr = e.tee(e.stderr)
+	if e.stderr == e.stdout {
+		// A shared writer must only be written by one goroutine at a time,
+		// which exec guarantees for identical writers
+		cmd.Stderr = cmd.Stdout
+	}
 
-	if err := cmd.Run(); err != nil {
+	err := cmd.Run()
+	for _, w := range []io.Writer{cmd.Stdout, cmd.Stderr} {
+		if tee, ok := w.(*teeWriter); ok {
+			//nolint:errcheck // Log output is best effort
+			tee.log.Close()
+		}
+	}
+	if err != nil {
 		return fmt.Errorf("docker command failed: %w", err)
 	}
 
 	return nil
 }
 
+// teeWriter writes to an output and the log file.
+type teeWriter struct {
+	io.Writer
+	log io.WriteCloser
+}
+
+// tee returns w copying into the log file when command output is logged and
+// w is not a terminal.
+func (e *DefaultDockerExecutor) tee(w io.Writer) io.Writer {
+	if e.logCommand == "" || w == nil || isTerminal(w) {
+		return w
+	}
+	log := e.logger.OutputWriter(e.logStack, e.logCommand)
+	return &teeWriter{Writer: io.MultiWriter(w, log), log: log}
+}
+
 // RunQuiet executes a Docker command and returns output without TTY.
-func (e *DefaultDockerExecutor) RunQuiet(args []string) ([]byte, error) {
+func (e *DefaultDockerExecutor) RunQuiet(ctx context.Context, args []string) ([]byte, error) {
 	if e.dryRun {
 		return nil, nil
 	}
 
-	cmd := exec.Command("docker", args...)
-	return cmd.Output()
+	return dockerCommand(ctx, args, true).Output()
 }
 
-// ComposeClient handles Docker Compose operations for a stack.
+// dockerCommand creates a docker command that is killed when ctx is done.
+// A command with its own process group is killed together with its children,
+// such as the compose plugin, and is not sent the signals of the terminal.
+// Commands attached to the terminal must stay in the foreground process
+// group, so only the docker process itself is killed.
+func dockerCommand(ctx context.Context, args []string, ownGroup bool) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	if ownGroup {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		cmd.Cancel = func() error {
+			return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		}
+	}
+	return cmd
+}
+
+// ComposeClient handles Docker Compose operations for a stack. Operations
+// that change containers always use the docker CLI through the executor;
+// queries use the backend selected by the query-backend setting.
 type ComposeClient struct {
 	executor DockerExecutor
+	query    DockerQuerier
 	logger   *Logger
 	config   *Config
 }
 
 // NewComposeClient creates a new Compose client.
 func NewComposeClient(executor DockerExecutor, logger *Logger, config *Config) *ComposeClient {
+	var query DockerQuerier = &cliQuerier{executor: executor}
+	if config.QueryBackend == QueryBackendAPI {
+		query = NewEngineClient(config.DockerSocket)
+	}
+
 	return &ComposeClient{
 		executor: executor,
+		query:    query,
 		logger:   logger,
 		config:   config,
 	}
 }
 
+// WithOutput returns a copy of the client whose commands write their output to w.
+// Executors that do not implement OutputRedirector are used unchanged.
+func (c *ComposeClient) WithOutput(w io.Writer) *ComposeClient {
+	redirector, ok := c.executor.(OutputRedirector)
+	if !ok {
+		return c
+	}
+
+	return &ComposeClient{
+		executor: redirector.WithOutput(w),
+		query:    c.query,
+		logger:   c.logger,
+		config:   c.config,
+	}
+}
+
+// WithLogger returns a copy of the client writing its entries to logger. The
+// executor keeps its own logger.
+func (c *ComposeClient) WithLogger(logger *Logger) *ComposeClient {
+	return &ComposeClient{
+		executor: c.executor,
+		query:    c.query,
+		logger:   logger,
+		config:   c.config,
+	}
+}
+
+// run executes a command that changes containers, limited by the configured
+// operation timeout. Its output is also written to the log file.
+func (c *ComposeClient) run(ctx context.Context, args []string) error {
+	executor := c.executor
+	if logger, ok := executor.(OutputLogger); ok {
+		executor = logger.WithOutputLog(outputSource(args))
+	}
+
+	timeout := c.config.OperationTimeoutDuration()
+	if timeout <= 0 {
+		return executor.Run(ctx, args)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := executor.Run(ctx, args)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("docker %s timed out after %s", commandName(args), timeout)
+	}
+	return err
+}
+
+// commandName returns a short name of a docker command for messages, such as
+// "compose up" or "image tag".
+func commandName(args []string) string {
+	if i := slices.Index(args, "--project-name"); i >= 0 && i+2 < len(args) {
+		return "compose " + args[i+2]
+	}
+	return strings.Join(args[:min(len(args), 2)], " ")
+}
+
+// outputSource returns the stack and command that command output is logged
+// as: the compose subcommand such as "up", or the command name for commands
+// not run on a stack.
+func outputSource(args []string) (stack, command string) {
+	if i := slices.Index(args, "--project-name"); i >= 0 && i+2 < len(args) {
+		return args[i+1], args[i+2]
+	}
+	return "", commandName(args)
+}
+
 // Up brings up a stack.
-func (c *ComposeClient) Up(stack *Stack, stackConfig *StackConfig) error {
+func (c *ComposeClient) Up(ctx context.Context, stack *Stack, stackConfig *StackConfig) error {
+	config := c.config.MergeStackConfig(stackConfig)
+	args := c.buildArgs(stack, config, "up")
+	args = append(args, config.UpArgs...)
+	return c.run(ctx, args)
+}
+
+// Recreate brings up a stack with its local images, never pulling newer ones.
+func (c *ComposeClient) Recreate(ctx context.Context, stack *Stack, stackConfig *StackConfig) error {
 	config := c.config.MergeStackConfig(stackConfig)
 	args := c.buildArgs(stack, config, "up")
 	args = append(args, config.UpArgs...)
-	return c.executor.Run(args)
+	// The last --pull wins over one in up-args
+	args = append(args, "--pull", "never")
+	return c.run(ctx, args)
+}
+
+// TagImage points the image reference ref at the image with the given ID.
+func (c *ComposeClient) TagImage(ctx context.Context, id, ref string) error {
+	return c.run(ctx, []string{"image", "tag", id, ref})
 }
 
 // Start starts a stopped stack.
-func (c *ComposeClient) Start(stack *Stack, stackConfig *StackConfig) error {
+func (c *ComposeClient) Start(ctx context.Context, stack *Stack, stackConfig *StackConfig) error {
 	config := c.config.MergeStackConfig(stackConfig)
 	args := c.buildArgs(stack, config, "start")
-	return c.executor.Run(args)
+	return c.run(ctx, args)
 }
 
 // Stop stops a stack.
-func (c *ComposeClient) Stop(stack *Stack, stackConfig *StackConfig) error {
+func (c *ComposeClient) Stop(ctx context.Context, stack *Stack, stackConfig *StackConfig) error {
 	config := c.config.MergeStackConfig(stackConfig)
 	args := c.buildArgs(stack, config, "stop")
-	args = append(args, "--timeout", fmt.Sprintf("%d", c.config.Timeout))
-	return c.executor.Run(args)
+	args = append(args, "--timeout", fmt.Sprintf("%d", config.Timeout))
+	return c.run(ctx, args)
 }
 
 // Down takes down a stack.
-func (c *ComposeClient) Down(stack *Stack, stackConfig *StackConfig) error {
+func (c *ComposeClient) Down(ctx context.Context, stack *Stack, stackConfig *StackConfig) error {
 	config := c.config.MergeStackConfig(stackConfig)
 	args := c.buildArgs(stack, config, "down")
 	args = append(args, config.DownArgs...)
-	return c.executor.Run(args)
+	return c.run(ctx, args)
+}
+
+// Logs shows the container logs of a stack.
+func (c *ComposeClient) Logs(ctx context.Context, stack *Stack, stackConfig *StackConfig, opts LogOptions) error {
+	config := c.config.MergeStackConfig(stackConfig)
+	args := c.buildArgs(stack, config, "logs")
+	args = append(args, opts.args()...)
+	if opts.Follow {
+		return c.runInteractive(ctx, args)
+	}
+	return c.executor.Run(ctx, args)
+}
+
+// Compose runs an arbitrary docker compose subcommand for a stack, such as
+// exec or pull. args starts with the subcommand.
+func (c *ComposeClient) Compose(ctx context.Context, stack *Stack, stackConfig *StackConfig, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no compose command given")
+	}
+
+	config := c.config.MergeStackConfig(stackConfig)
+	composeArgs := c.buildArgs(stack, config, args[0])
+	composeArgs = append(composeArgs, args[1:]...)
+	return c.runInteractive(ctx, composeArgs)
+}
+
+// runInteractive executes a command attached to the terminal if the executor
+// supports it.
+func (c *ComposeClient) runInteractive(ctx context.Context, args []string) error {
+	if runner, ok := c.executor.(InteractiveRunner); ok {
+		return runner.RunInteractive(ctx, args)
+	}
+	return c.executor.Run(ctx, args)
+}
+
+// Pull pulls the images of a stack without recreating its containers.
+func (c *ComposeClient) Pull(ctx context.Context, stack *Stack, stackConfig *StackConfig) error {
+	config := c.config.MergeStackConfig(stackConfig)
+	args := c.buildArgs(stack, config, "pull")
+	args = append(args, "--quiet")
+	return c.run(ctx, args)
+}
+
+// ImageUpdates returns the services of a stack whose containers run an image
+// other than the one their image reference currently resolves to locally,
+// i.e. services that would get a newer image when recreated after a pull.
+func (c *ComposeClient) ImageUpdates(ctx context.Context, stack *Stack) ([]ImageUpdate, error) {
+	services, err := c.Services(ctx, stack)
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []ImageUpdate
+	seen := make(map[string]bool)
+	for _, service := range services {
+		if seen[service.Service] || service.Image == "" {
+			continue
+		}
+		seen[service.Service] = true
+
+		current, err := c.query.ContainerImage(ctx, service.Container)
+		if err != nil {
+			return nil, fmt.Errorf("inspecting container %s: %w", service.Container, err)
+		}
+		latest, err := c.query.ImageID(ctx, service.Image)
+		if err != nil {
+			return nil, fmt.Errorf("inspecting image %s: %w", service.Image, err)
+		}
+
+		if current != latest {
+			updates = append(updates, ImageUpdate{
+				Service: service.Service,
+				Image:   service.Image,
+				Current: current,
+				Latest:  latest,
+			})
+		}
+	}
+
+	return updates, nil
+}
+
+// CheckConfig runs docker compose config for a stack and returns an error
+// with the message of compose if the configuration is invalid.
+func (c *ComposeClient) CheckConfig(ctx context.Context, stack *Stack, stackConfig *StackConfig) error {
+	config := c.config.MergeStackConfig(stackConfig)
+	args := c.buildArgs(stack, config, "config")
+	args = append(args, "--quiet")
+
+	if _, err := c.executor.RunQuiet(ctx, args); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(bytes.TrimSpace(exitErr.Stderr)) > 0 {
+			return errors.New(string(bytes.TrimSpace(exitErr.Stderr)))
+		}
+		return err
+	}
+	return nil
+}
+
+// DefinedServices returns the names of the services defined in the compose
+// files of a stack, whether or not they have containers.
+func (c *ComposeClient) DefinedServices(ctx context.Context, stack *Stack, stackConfig *StackConfig) ([]string, error) {
+	config := c.config.MergeStackConfig(stackConfig)
+	args := c.buildArgs(stack, config, "config")
+	args = append(args, "--services")
+
+	output, err := c.executor.RunQuiet(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(output)), nil
 }
 
 // HasContainers checks if a stack has any containers.
-func (c *ComposeClient) HasContainers(stack *Stack) bool {
+func (c *ComposeClient) HasContainers(ctx context.Context, stack *Stack) bool {
+	exists, err := c.query.HasContainers(ctx, stack)
+	if err != nil {
+		c.logger.Debug("Failed to check containers for stack %s: %v", stack.Name, err)
+		return false
+	}
+	return exists
+}
+
+// Services returns the state of every container of a stack.
+func (c *ComposeClient) Services(ctx context.Context, stack *Stack) ([]ServiceState, error) {
+	return c.query.Services(ctx, stack)
+}
+
+// ProjectInfo describes a Docker Compose project as reported by compose ls.
+type ProjectInfo struct {
+	Containers map[string]int
+	Status     StackStatus
+}
+
+// GetProjects returns status and container counts for all Docker Compose projects.
+func (c *ComposeClient) GetProjects(ctx context.Context) map[string]ProjectInfo {
+	projects, err := c.query.Projects(ctx)
+	if err != nil {
+		c.logger.Debug("Failed to get compose statuses: %v", err)
+		return make(map[string]ProjectInfo)
+	}
+	return projects
+}
+
+// GetProjectStatuses returns status for all Docker Compose projects.
+func (c *ComposeClient) GetProjectStatuses(ctx context.Context) map[string]StackStatus {
+	statuses := make(map[string]StackStatus)
+	for name, project := range c.GetProjects(ctx) {
+		statuses[name] = project.Status
+	}
+	return statuses
+}
+
+func (c *ComposeClient) buildArgs(stack *Stack, config *Config, action string) []string {
+	args := []string{"compose"}
+	args = append(args, config.CommonArgs...)
+	for _, file := range config.ComposeFiles {
+		args = append(args, "--file", file)
+	}
+	for _, envFile := range config.EnvFiles {
+		args = append(args, "--env-file", envFile)
+	}
+	for _, profile := range config.Profiles {
+		args = append(args, "--profile", profile)
+	}
+	args = append(args, "--project-directory", stack.Dir, "--project-name", stack.Name, action)
+	return args
+}
+
+// cliQuerier implements DockerQuerier with docker CLI commands.
+type cliQuerier struct {
+	executor DockerExecutor
+}
+
+func (q *cliQuerier) HasContainers(ctx context.Context, stack *Stack) (bool, error) {
 	args := []string{
 		"compose",
 		"--project-directory", stack.Dir,
@@ -115,52 +521,87 @@ func (c *ComposeClient) HasContainers(stack *Stack) bool {
 		"ps", "-a", "-q",
 	}
 
-	output, err := c.executor.RunQuiet(args)
+	output, err := q.executor.RunQuiet(ctx, args)
 	if err != nil {
-		c.logger.Debug("Failed to check containers for stack %s: %v", stack.Name, err)
-		return false
+		return false, err
 	}
 
-	return strings.TrimSpace(string(output)) != ""
+	return strings.TrimSpace(string(output)) != "", nil
 }
 
-// GetProjectStatuses returns status for all Docker Compose projects.
-func (c *ComposeClient) GetProjectStatuses() map[string]StackStatus {
-	statuses := make(map[string]StackStatus)
+func (q *cliQuerier) Services(ctx context.Context, stack *Stack) ([]ServiceState, error) {
+	args := []string{
+		"compose",
+		"--project-directory", stack.Dir,
+		"--project-name", stack.Name,
+		"ps", "--all", "--format", "json",
+	}
+
+	output, err := q.executor.RunQuiet(ctx, args)
+	if err != nil {
+		return nil, fmt.Errorf("listing containers of stack %s: %w", stack.Name, err)
+	}
 
+	services, err := parseServiceStates(output)
+	if err != nil {
+		return nil, fmt.Errorf("parsing containers of stack %s: %w", stack.Name, err)
+	}
+
+	return services, nil
+}
+
+func (q *cliQuerier) Projects(ctx context.Context) (map[string]ProjectInfo, error) {
 	args := []string{"compose", "ls", "--all", "--format", "json"}
-	output, err := c.executor.RunQuiet(args)
+	output, err := q.executor.RunQuiet(ctx, args)
 	if err != nil {
-		c.logger.Debug("Failed to get compose statuses: %v", err)
-		return statuses
+		return nil, err
 	}
 
-	var projects []struct {
+	var entries []struct {
 		Name   string `json:"Name"`
 		Status string `json:"Status"`
 	}
 
-	if err := json.Unmarshal(output, &projects); err != nil {
-		c.logger.Debug("Failed to parse compose statuses: %v", err)
-		return statuses
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, fmt.Errorf("parsing compose ls output: %w", err)
 	}
 
-	for _, project := range projects {
-		statuses[project.Name] = normalizeStatus(project.Status)
+	projects := make(map[string]ProjectInfo, len(entries))
+	for _, entry := range entries {
+		projects[entry.Name] = ProjectInfo{
+			Containers: parseContainerCounts(entry.Status),
+			Status:     normalizeStatus(entry.Status),
+		}
 	}
 
-	return statuses
+	return projects, nil
 }
 
-func (c *ComposeClient) buildArgs(stack *Stack, config *Config, action string) []string {
-	args := []string{"compose"}
-	args = append(args, config.CommonArgs...)
-	args = append(args, "--project-directory", stack.Dir, "--project-name", stack.Name, action)
-	return args
+func (q *cliQuerier) ContainerImage(ctx context.Context, container string) (string, error) {
+	return q.inspect(ctx, []string{"inspect", "--format", "{{.Image}}", container})
+}
+
+func (q *cliQuerier) ImageID(ctx context.Context, ref string) (string, error) {
+	return q.inspect(ctx, []string{"image", "inspect", "--format", "{{.Id}}", ref})
+}
+
+// inspect runs a docker inspect command and returns its trimmed output.
+func (q *cliQuerier) inspect(ctx context.Context, args []string) (string, error) {
+	output, err := q.executor.RunQuiet(ctx, args)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
 }
 
 // normalizeStatus converts docker compose status to StackStatus.
 func normalizeStatus(status string) StackStatus {
+	// Mixed states: "running(2), exited(1)" -> degraded
+	counts := parseContainerCounts(status)
+	if counts["running"] > 0 && len(counts) > 1 {
+		return StackStatusDegraded
+	}
+
 	// Remove count in parentheses: "running(1)" -> "running"
 	if idx := strings.Index(status, "("); idx != -1 {
 		status = status[:idx]
@@ -175,3 +616,73 @@ func normalizeStatus(status string) StackStatus {
 		return StackStatusDown
 	}
 }
+
+// parseContainerCounts converts docker compose status to container counts per state.
+// Format: "running(2), exited(1)" -> {"running": 2, "exited": 1}
+func parseContainerCounts(status string) map[string]int {
+	counts := make(map[string]int)
+
+	for _, part := range strings.Split(status, ",") {
+		part = strings.TrimSpace(part)
+		open := strings.Index(part, "(")
+		if open == -1 || !strings.HasSuffix(part, ")") {
+			continue
+		}
+
+		count, err := strconv.Atoi(part[open+1 : len(part)-1])
+		if err != nil {
+			continue
+		}
+		counts[part[:open]] += count
+	}
+
+	return counts
+}
+
+// composePsEntry is a container entry of compose ps JSON output.
+type composePsEntry struct {
+	Service  string `json:"Service"`
+	Name     string `json:"Name"`
+	State    string `json:"State"`
+	Health   string `json:"Health"`
+	Status   string `json:"Status"`
+	Image    string `json:"Image"`
+	ExitCode int    `json:"ExitCode"`
+}
+
+// parseServiceStates parses compose ps JSON output. Older Compose versions
+// print a JSON array, newer ones print one JSON object per line.
+func parseServiceStates(output []byte) ([]ServiceState, error) {
+	var entries []composePsEntry
+
+	trimmed := bytes.TrimSpace(output)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, err
+		}
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		for decoder.More() {
+			var entry composePsEntry
+			if err := decoder.Decode(&entry); err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	services := make([]ServiceState, 0, len(entries))
+	for _, entry := range entries {
+		services = append(services, ServiceState{
+			Service:   entry.Service,
+			Container: entry.Name,
+			State:     entry.State,
+			Health:    entry.Health,
+			Status:    entry.Status,
+			Image:     entry.Image,
+			ExitCode:  entry.ExitCode,
+		})
+	}
+
+	return services, nil
+}
@@ -1,8 +1,17 @@
 package loader
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 )
 
 func TestComposeClientHasContainers(t *testing.T) {
@@ -12,7 +21,7 @@ func TestComposeClientHasContainers(t *testing.T) {
 		mock := &MockDockerExecutor{RunQuietOut: []byte("abc123\n")}
 		client := NewComposeClient(mock, newTestLogger(t), &Config{})
 
-		if !client.HasContainers(stack) {
+		if !client.HasContainers(t.Context(), stack) {
 			t.Error("Expected true when containers exist")
 		}
 		if len(mock.RunQuietCalls) != 1 {
@@ -24,7 +33,7 @@ func TestComposeClientHasContainers(t *testing.T) {
 		mock := &MockDockerExecutor{RunQuietOut: []byte("")}
 		client := NewComposeClient(mock, newTestLogger(t), &Config{})
 
-		if client.HasContainers(stack) {
+		if client.HasContainers(t.Context(), stack) {
 			t.Error("Expected false when no containers")
 		}
 	})
@@ -33,7 +42,7 @@ func TestComposeClientHasContainers(t *testing.T) {
 		mock := &MockDockerExecutor{RunQuietError: errors.New("docker error")}
 		client := NewComposeClient(mock, newTestLogger(t), &Config{})
 
-		if client.HasContainers(stack) {
+		if client.HasContainers(t.Context(), stack) {
 			t.Error("Expected false on error")
 		}
 	})
@@ -46,7 +55,7 @@ func TestComposeClientGetProjectStatuses(t *testing.T) {
 		}
 		client := NewComposeClient(mock, newTestLogger(t), &Config{})
 
-		statuses := client.GetProjectStatuses()
+		statuses := client.GetProjectStatuses(t.Context())
 		if statuses["web"] != StackStatusRunning {
 			t.Errorf("Expected web=running, got %s", statuses["web"])
 		}
@@ -59,13 +68,70 @@ func TestComposeClientGetProjectStatuses(t *testing.T) {
 		mock := &MockDockerExecutor{RunQuietError: errors.New("error")}
 		client := NewComposeClient(mock, newTestLogger(t), &Config{})
 
-		statuses := client.GetProjectStatuses()
+		statuses := client.GetProjectStatuses(t.Context())
 		if len(statuses) != 0 {
 			t.Error("Expected empty map on error")
 		}
 	})
 }
 
+func TestComposeClientServices(t *testing.T) {
+	stack := &Stack{Name: "web", Dir: "/stacks/01-web"}
+
+	t.Run("parses json array output", func(t *testing.T) {
+		mock := &MockDockerExecutor{
+			RunQuietOut: []byte(`[{"Service":"web","Name":"web-1","State":"running","Health":"healthy"}]`),
+		}
+		client := NewComposeClient(mock, newTestLogger(t), &Config{})
+
+		services, err := client.Services(t.Context(), stack)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(services) != 1 || services[0].Container != "web-1" || services[0].Health != "healthy" {
+			t.Errorf("Unexpected services: %+v", services)
+		}
+	})
+
+	t.Run("parses json lines output", func(t *testing.T) {
+		mock := &MockDockerExecutor{
+			RunQuietOut: []byte(`{"Service":"web","State":"running"}` + "\n" +
+				`{"Service":"db","State":"exited","ExitCode":137}` + "\n"),
+		}
+		client := NewComposeClient(mock, newTestLogger(t), &Config{})
+
+		services, err := client.Services(t.Context(), stack)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(services) != 2 || services[1].Service != "db" || services[1].ExitCode != 137 {
+			t.Errorf("Unexpected services: %+v", services)
+		}
+	})
+
+	t.Run("empty output has no services", func(t *testing.T) {
+		mock := &MockDockerExecutor{RunQuietOut: []byte("")}
+		client := NewComposeClient(mock, newTestLogger(t), &Config{})
+
+		services, err := client.Services(t.Context(), stack)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(services) != 0 {
+			t.Errorf("Expected no services, got %+v", services)
+		}
+	})
+
+	t.Run("returns error on failure", func(t *testing.T) {
+		mock := &MockDockerExecutor{RunQuietError: errors.New("docker error")}
+		client := NewComposeClient(mock, newTestLogger(t), &Config{})
+
+		if _, err := client.Services(t.Context(), stack); err == nil {
+			t.Error("Expected error")
+		}
+	})
+}
+
 func TestComposeClientOperations(t *testing.T) {
 	stack := &Stack{Name: "web", Dir: "/stacks/01-web"}
 	config := &Config{Timeout: 10}
@@ -76,10 +142,10 @@ func TestComposeClientOperations(t *testing.T) {
 		operation func(*ComposeClient) error
 		wantCmd   string
 	}{
-		{"Up", func(c *ComposeClient) error { return c.Up(stack, stackConfig) }, "up"},
-		{"Start", func(c *ComposeClient) error { return c.Start(stack, stackConfig) }, "start"},
-		{"Stop", func(c *ComposeClient) error { return c.Stop(stack, stackConfig) }, "stop"},
-		{"Down", func(c *ComposeClient) error { return c.Down(stack, stackConfig) }, "down"},
+		{"Up", func(c *ComposeClient) error { return c.Up(t.Context(), stack, stackConfig) }, "up"},
+		{"Start", func(c *ComposeClient) error { return c.Start(t.Context(), stack, stackConfig) }, "start"},
+		{"Stop", func(c *ComposeClient) error { return c.Stop(t.Context(), stack, stackConfig) }, "stop"},
+		{"Down", func(c *ComposeClient) error { return c.Down(t.Context(), stack, stackConfig) }, "down"},
 	}
 
 	for _, tt := range tests {
@@ -101,10 +167,90 @@ func TestComposeClientOperations(t *testing.T) {
 	}
 }
 
+func TestComposeClientStackConfig(t *testing.T) {
+	stack := &Stack{Name: "web", Dir: "/stacks/01-web"}
+	config := &Config{Timeout: 10, EnvFiles: []string{"/base/.env"}}
+	timeout := 60
+	stackConfig := &StackConfig{
+		EnvFiles:     []string{"/stacks/01-web/app.env"},
+		Profiles:     []string{"debug"},
+		ComposeFiles: []string{"/stacks/01-web/compose.yaml"},
+		Timeout:      &timeout,
+		Merge:        map[string]MergeStrategy{"env-files": MergeAppend},
+	}
+
+	mock := &MockDockerExecutor{}
+	client := NewComposeClient(mock, newTestLogger(t), config)
+	if err := client.Stop(t.Context(), stack, stackConfig); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []string{
+		"compose", "--file", "/stacks/01-web/compose.yaml",
+		"--env-file", "/base/.env", "--env-file", "/stacks/01-web/app.env", "--profile", "debug",
+		"--project-directory", "/stacks/01-web", "--project-name", "web", "stop", "--timeout", "60",
+	}
+	assertSliceEqual(t, mock.RunCalls[0], want)
+}
+
+func TestComposeClientCompose(t *testing.T) {
+	stack := &Stack{Name: "web", Dir: "/stacks/01-web"}
+	config := &Config{CommonArgs: []string{"--env-file", "/base/.env"}}
+
+	t.Run("passes arguments through", func(t *testing.T) {
+		mock := &MockDockerExecutor{}
+		client := NewComposeClient(mock, newTestLogger(t), config)
+
+		if err := client.Compose(t.Context(), stack, &StackConfig{}, []string{"exec", "app", "sh"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		assertSliceEqual(t, mock.RunCalls[0], []string{
+			"compose", "--env-file", "/base/.env", "--project-directory", "/stacks/01-web",
+			"--project-name", "web", "exec", "app", "sh",
+		})
+	})
+
+	t.Run("requires a command", func(t *testing.T) {
+		mock := &MockDockerExecutor{}
+		client := NewComposeClient(mock, newTestLogger(t), config)
+
+		if err := client.Compose(t.Context(), stack, &StackConfig{}, nil); err == nil {
+			t.Error("Expected error without compose command")
+		}
+		if len(mock.RunCalls) != 0 {
+			t.Errorf("Expected no calls, got %v", mock.RunCalls)
+		}
+	})
+}
+
+func TestComposeClientWithOutput(t *testing.T) {
+	t.Run("redirects default executor", func(t *testing.T) {
+		executor := NewDockerExecutor(newTestLogger(t), true)
+		client := NewComposeClient(executor, newTestLogger(t), &Config{})
+
+		redirected := client.WithOutput(io.Discard)
+		if redirected == client {
+			t.Error("Expected a new client")
+		}
+		if redirected.executor == client.executor {
+			t.Error("Expected a new executor")
+		}
+	})
+
+	t.Run("keeps executor without redirect support", func(t *testing.T) {
+		mock := &MockDockerExecutor{}
+		client := NewComposeClient(mock, newTestLogger(t), &Config{})
+
+		if client.WithOutput(io.Discard) != client {
+			t.Error("Expected the same client for non-redirectable executor")
+		}
+	})
+}
+
 func TestDefaultDockerExecutor(t *testing.T) {
 	t.Run("dry run does not execute", func(t *testing.T) {
 		executor := NewDockerExecutor(newTestLogger(t), true)
-		err := executor.Run([]string{"version"})
+		err := executor.Run(t.Context(), []string{"version"})
 		if err != nil {
 			t.Errorf("Dry run should not error: %v", err)
 		}
@@ -112,7 +258,7 @@ func TestDefaultDockerExecutor(t *testing.T) {
 
 	t.Run("RunQuiet returns nil in dry run", func(t *testing.T) {
 		executor := NewDockerExecutor(newTestLogger(t), true)
-		output, err := executor.RunQuiet([]string{"version"})
+		output, err := executor.RunQuiet(t.Context(), []string{"version"})
 		if err != nil {
 			t.Errorf("Dry run should not error: %v", err)
 		}
@@ -120,4 +266,221 @@ func TestDefaultDockerExecutor(t *testing.T) {
 			t.Errorf("Dry run should return nil output, got: %v", output)
 		}
 	})
+
+	t.Run("copies output into the log", func(t *testing.T) {
+		dir := fakeDocker(t, "echo created\necho started >&2\n")
+
+		logPath := filepath.Join(dir, "test.log")
+		logger, err := NewLogger(logPath, false)
+		if err != nil {
+			t.Fatalf("NewLogger failed: %v", err)
+		}
+		t.Cleanup(func() {
+			//nolint:errcheck // Test cleanup, error not critical
+			logger.Close()
+		})
+
+		var out bytes.Buffer
+		executor := NewDockerExecutor(logger, false).WithOutput(&out)
+		executor = executor.(OutputLogger).WithOutputLog("web", "up")
+		if err := executor.Run(t.Context(), []string{"compose", "up"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if out.String() != "created\nstarted\n" {
+			t.Errorf("Expected output passed through, got %q", out.String())
+		}
+		content, err := os.ReadFile(logPath)
+		if err != nil {
+			t.Fatalf("Failed to read log: %v", err)
+		}
+		for _, want := range []string{"OUTPUT: web/up | created", "OUTPUT: web/up | started"} {
+			if !strings.Contains(string(content), want) {
+				t.Errorf("Expected %q in log, got:\n%s", want, content)
+			}
+		}
+	})
+
+	t.Run("copies output written to /dev/null into the log", func(t *testing.T) {
+		dir := fakeDocker(t, "echo created\n")
+
+		devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			//nolint:errcheck // Test cleanup, error not critical
+			devNull.Close()
+		})
+		if isTerminal(devNull) {
+			t.Fatalf("Expected %s not to be a terminal", os.DevNull)
+		}
+
+		logPath := filepath.Join(dir, "test.log")
+		logger, err := NewLogger(logPath, false)
+		if err != nil {
+			t.Fatalf("NewLogger failed: %v", err)
+		}
+		t.Cleanup(func() {
+			//nolint:errcheck // Test cleanup, error not critical
+			logger.Close()
+		})
+
+		base := NewDockerExecutor(logger, false)
+		base.stdout = devNull
+		base.stderr = devNull
+		executor := base.WithOutputLog("web", "up")
+		if err := executor.Run(t.Context(), []string{"compose", "up"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		content, err := os.ReadFile(logPath)
+		if err != nil {
+			t.Fatalf("Failed to read log: %v", err)
+		}
+		if !strings.Contains(string(content), "OUTPUT: web/up | created") {
+			t.Errorf("Expected output in log, got:\n%s", content)
+		}
+	})
+
+	t.Run("timeout kills the process group", func(t *testing.T) {
+		// The child keeps stdout open, so Output only returns once it is killed too
+		fakeDocker(t, "sleep 30 &\nwait\n")
+
+		ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		executor := NewDockerExecutor(newTestLogger(t), false)
+		if _, err := executor.RunQuiet(ctx, []string{"compose", "ps"}); err == nil {
+			t.Error("Expected error for killed command")
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Command was not killed with its children, took %s", elapsed)
+		}
+	})
+
+	t.Run("Run kills the process group on timeout", func(t *testing.T) {
+		fakeDocker(t, "sleep 30 &\nwait\n")
+
+		ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		var out bytes.Buffer
+		executor := NewDockerExecutor(newTestLogger(t), false).WithOutput(&out)
+		if err := executor.Run(ctx, []string{"compose", "up"}); err == nil {
+			t.Error("Expected error for killed command")
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Command was not killed with its children, took %s", elapsed)
+		}
+	})
+
+	t.Run("only interactive commands share the process group", func(t *testing.T) {
+		// The fifth field of /proc/<pid>/stat is the process group
+		fakeDocker(t, "cut -d' ' -f5 /proc/$$/stat\n")
+		own := strconv.Itoa(syscall.Getpgrp())
+
+		var out bytes.Buffer
+		executor := NewDockerExecutor(newTestLogger(t), false).WithOutput(&out)
+		if err := executor.Run(t.Context(), []string{"compose", "up"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if group := strings.TrimSpace(out.String()); group == own {
+			t.Errorf("Expected Run in its own process group, got %s", group)
+		}
+
+		out.Reset()
+		if err := executor.(InteractiveRunner).RunInteractive(t.Context(), []string{"compose", "exec"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if group := strings.TrimSpace(out.String()); group != own {
+			t.Errorf("Expected RunInteractive in process group %s, got %s", own, group)
+		}
+	})
+}
+
+// fakeDocker puts a docker command running script first in PATH and returns
+// its directory.
+func fakeDocker(t *testing.T, script string) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, dir, "docker", "#!/bin/sh\n"+script)
+	if err := os.Chmod(filepath.Join(dir, "docker"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return dir
+}
+
+// blockingExecutor runs commands until their context is done.
+type blockingExecutor struct{}
+
+func (blockingExecutor) Run(ctx context.Context, _ []string) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (blockingExecutor) RunQuiet(ctx context.Context, _ []string) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestOutputSource(t *testing.T) {
+	stack := &Stack{Name: "web", Dir: "/stacks/01-web"}
+	client := NewComposeClient(&MockDockerExecutor{}, newTestLogger(t), &Config{CommonArgs: []string{"--ansi", "never"}})
+
+	if name, command := outputSource(client.buildArgs(stack, client.config, "up")); name != "web" || command != "up" {
+		t.Errorf("Expected web and up, got %q and %q", name, command)
+	}
+	name, command := outputSource([]string{"image", "tag", "sha256:1", "nginx:latest"})
+	if name != "" || command != "image tag" {
+		t.Errorf("Expected no stack and image tag, got %q and %q", name, command)
+	}
+}
+
+func TestComposeClientOperationTimeout(t *testing.T) {
+	stack := &Stack{Name: "web", Dir: "/stacks/01-web"}
+	client := NewComposeClient(blockingExecutor{}, newTestLogger(t), &Config{OperationTimeout: 1})
+
+	err := client.Up(t.Context(), stack, &StackConfig{})
+	if err == nil || err.Error() != "docker compose up timed out after 1s" {
+		t.Errorf("Expected timeout error, got: %v", err)
+	}
+}
+
+// interactiveExecutor records the commands run attached to the terminal.
+type interactiveExecutor struct {
+	MockDockerExecutor
+	interactive [][]string
+}
+
+func (e *interactiveExecutor) RunInteractive(_ context.Context, args []string) error {
+	e.interactive = append(e.interactive, args)
+	return nil
+}
+
+func TestComposeClientInteractiveCommands(t *testing.T) {
+	stack := &Stack{Name: "web", Dir: "/stacks/01-web"}
+	executor := &interactiveExecutor{}
+	client := NewComposeClient(executor, newTestLogger(t), &Config{})
+
+	for _, err := range []error{
+		client.Compose(t.Context(), stack, &StackConfig{}, []string{"exec", "app", "sh"}),
+		client.Logs(t.Context(), stack, &StackConfig{}, LogOptions{Follow: true}),
+		client.Logs(t.Context(), stack, &StackConfig{}, LogOptions{Tail: "10"}),
+		client.Up(t.Context(), stack, &StackConfig{}),
+	} {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if len(executor.interactive) != 2 || executor.interactive[0][5] != "exec" || executor.interactive[1][5] != "logs" {
+		t.Errorf("Expected exec and logs --follow run interactively, got %v", executor.interactive)
+	}
+	if len(executor.RunCalls) != 2 {
+		t.Errorf("Expected logs without --follow and up run detached, got %v", executor.RunCalls)
+	}
 }
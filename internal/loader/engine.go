@@ -0,0 +1,259 @@
+package loader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDockerSocket is the Engine API socket used when docker-socket is not set.
+const DefaultDockerSocket = "/var/run/docker.sock"
+
+// engineTimeout limits each Engine API request.
+const engineTimeout = 30 * time.Second
+
+// composeProjectLabel, composeServiceLabel and composeOneoffLabel are set by
+// Docker Compose on the containers it creates. composeOneoffLabel is True
+// for containers of docker compose run.
+const (
+	composeProjectLabel = "com.docker.compose.project"
+	composeServiceLabel = "com.docker.compose.service"
+	composeOneoffLabel  = "com.docker.compose.oneoff"
+)
+
+// EngineClient implements DockerQuerier with the Docker Engine API, avoiding
+// a docker process per query.
+type EngineClient struct {
+	client *http.Client
+}
+
+// NewEngineClient creates an Engine API client for the unix socket at path,
+// or DefaultDockerSocket if path is empty.
+func NewEngineClient(path string) *EngineClient {
+	if path == "" {
+		path = DefaultDockerSocket
+	}
+
+	var dialer net.Dialer
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", path)
+		},
+	}
+
+	return &EngineClient{
+		client: &http.Client{Transport: transport, Timeout: engineTimeout},
+	}
+}
+
+// engineContainer is a container entry of the Engine API container list.
+type engineContainer struct {
+	Labels map[string]string `json:"Labels"`
+	ID     string            `json:"Id"`
+	Image  string            `json:"Image"`
+	State  string            `json:"State"`
+	Status string            `json:"Status"`
+	Names  []string          `json:"Names"`
+}
+
+// HasContainers reports whether a stack has any containers.
+func (e *EngineClient) HasContainers(ctx context.Context, stack *Stack) (bool, error) {
+	containers, err := e.containers(ctx, composeProjectLabel+"="+stack.Name)
+	if err != nil {
+		return false, err
+	}
+	return len(containers) > 0, nil
+}
+
+// Services returns the state of every container of a stack.
+func (e *EngineClient) Services(ctx context.Context, stack *Stack) ([]ServiceState, error) {
+	containers, err := e.containers(ctx, composeProjectLabel+"="+stack.Name)
+	if err != nil {
+		return nil, fmt.Errorf("listing containers of stack %s: %w", stack.Name, err)
+	}
+
+	services := make([]ServiceState, 0, len(containers))
+	for _, container := range containers {
+		image := container.Image
+		if strings.HasPrefix(image, "sha256:") {
+			// The list shows the image ID once the reference the container
+			// was created from points to another image
+			if image, err = e.configuredImage(ctx, container.ID); err != nil {
+				return nil, fmt.Errorf("inspecting container %s: %w", container.ID, err)
+			}
+		}
+
+		health, exitCode := parseContainerStatus(container.Status)
+		services = append(services, ServiceState{
+			Service:   container.Labels[composeServiceLabel],
+			Container: containerName(container),
+			State:     container.State,
+			Health:    health,
+			Status:    container.Status,
+			Image:     image,
+			ExitCode:  exitCode,
+		})
+	}
+
+	return services, nil
+}
+
+// Projects returns the status of every Docker Compose project by name.
+func (e *EngineClient) Projects(ctx context.Context) (map[string]ProjectInfo, error) {
+	containers, err := e.containers(ctx, composeProjectLabel)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]map[string]int)
+	for _, container := range containers {
+		name := container.Labels[composeProjectLabel]
+		if counts[name] == nil {
+			counts[name] = make(map[string]int)
+		}
+		counts[name][container.State]++
+	}
+
+	projects := make(map[string]ProjectInfo, len(counts))
+	for name, states := range counts {
+		projects[name] = ProjectInfo{
+			Containers: states,
+			Status:     statusFromCounts(states),
+		}
+	}
+
+	return projects, nil
+}
+
+// ContainerImage returns the ID of the image a container runs.
+func (e *EngineClient) ContainerImage(ctx context.Context, container string) (string, error) {
+	var info struct {
+		Image string `json:"Image"`
+	}
+	if err := e.get(ctx, "/containers/"+container+"/json", nil, &info); err != nil {
+		return "", err
+	}
+	return info.Image, nil
+}
+
+// ImageID returns the ID of the local image an image reference points to.
+func (e *EngineClient) ImageID(ctx context.Context, ref string) (string, error) {
+	var info struct {
+		ID string `json:"Id"`
+	}
+	if err := e.get(ctx, "/images/"+ref+"/json", nil, &info); err != nil {
+		return "", err
+	}
+	return info.ID, nil
+}
+
+// containers lists all containers, including stopped ones, that have the
+// given label filter ("key" or "key=value"). One-off containers are left
+// out, as docker compose ps and ls do.
+func (e *EngineClient) containers(ctx context.Context, label string) ([]engineContainer, error) {
+	filters, err := json.Marshal(map[string][]string{"label": {label, composeOneoffLabel + "=False"}})
+	if err != nil {
+		return nil, err
+	}
+
+	var containers []engineContainer
+	query := url.Values{"all": {"1"}, "filters": {string(filters)}}
+	if err := e.get(ctx, "/containers/json", query, &containers); err != nil {
+		return nil, err
+	}
+	return containers, nil
+}
+
+// configuredImage returns the image reference a container was created from.
+func (e *EngineClient) configuredImage(ctx context.Context, container string) (string, error) {
+	var info struct {
+		Config struct {
+			Image string `json:"Image"`
+		} `json:"Config"`
+	}
+	if err := e.get(ctx, "/containers/"+container+"/json", nil, &info); err != nil {
+		return "", err
+	}
+	return info.Config.Image, nil
+}
+
+// get requests an Engine API path and decodes the JSON response into v.
+func (e *EngineClient) get(ctx context.Context, path string, query url.Values, v any) error {
+	target := url.URL{Scheme: "http", Host: "docker", Path: path, RawQuery: query.Encode()}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("docker engine API: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // Response body is only read
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		body, _ := io.ReadAll(resp.Body) //nolint:errcheck // Error message is best effort
+		if json.Unmarshal(body, &apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(body))
+		}
+		return fmt.Errorf("docker engine API: GET %s: %s (%s)", path, apiErr.Message, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("docker engine API: decoding %s: %w", path, err)
+	}
+	return nil
+}
+
+// containerName returns the name of a container without the leading slash.
+func containerName(container engineContainer) string {
+	if len(container.Names) == 0 {
+		return container.ID
+	}
+	return strings.TrimPrefix(container.Names[0], "/")
+}
+
+var (
+	healthPattern   = regexp.MustCompile(`\((healthy|unhealthy|health: starting)\)`)
+	exitCodePattern = regexp.MustCompile(`^Exited \((\d+)\)`)
+)
+
+// parseContainerStatus extracts the health and exit code from a container
+// status such as "Up 2 hours (healthy)" or "Exited (137) 5 minutes ago",
+// using the values compose ps reports.
+func parseContainerStatus(status string) (health string, exitCode int) {
+	if match := healthPattern.FindStringSubmatch(status); match != nil {
+		health = strings.TrimPrefix(match[1], "health: ")
+	}
+	if match := exitCodePattern.FindStringSubmatch(status); match != nil {
+		exitCode, _ = strconv.Atoi(match[1]) //nolint:errcheck // Pattern only matches digits
+	}
+	return health, exitCode
+}
+
+// statusFromCounts derives the stack status from container counts per
+// state, matching the status normalizeStatus derives from compose ls.
+func statusFromCounts(counts map[string]int) StackStatus {
+	switch {
+	case counts["running"] > 0 && len(counts) > 1:
+		return StackStatusDegraded
+	case counts["running"] > 0:
+		return StackStatusRunning
+	case counts["exited"] > 0:
+		return StackStatusStopped
+	default:
+		return StackStatusDown
+	}
+}
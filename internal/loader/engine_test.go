@@ -0,0 +1,213 @@
+package loader
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newFakeEngine serves handler on a unix socket and returns its path.
+func newFakeEngine(t *testing.T, handler http.Handler) string {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Failed to listen on %s: %v", socket, err)
+	}
+
+	server := httptest.NewUnstartedServer(handler)
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+	return socket
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, v any) {
+	t.Helper()
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		t.Errorf("Failed to encode response: %v", err)
+	}
+}
+
+// matchesLabels reports whether labels match every label filter, as the
+// Engine API does.
+func matchesLabels(labels map[string]string, filters []string) bool {
+	for _, filter := range filters {
+		key, value, hasValue := strings.Cut(filter, "=")
+		actual, ok := labels[key]
+		if !ok || hasValue && actual != value {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEngineClient(t *testing.T) {
+	containers := []map[string]any{
+		{
+			"Id": "c1", "Names": []string{"/web-app-1"}, "Image": "nginx:latest",
+			"State": "running", "Status": "Up 2 hours (healthy)",
+			"Labels": map[string]string{composeProjectLabel: "web", composeServiceLabel: "app", composeOneoffLabel: "False"},
+		},
+		{
+			"Id": "c0", "Names": []string{"/web-app-run-1"}, "Image": "nginx:latest",
+			"State": "running", "Status": "Up 1 minute",
+			"Labels": map[string]string{composeProjectLabel: "web", composeServiceLabel: "app", composeOneoffLabel: "True"},
+		},
+		{
+			"Id": "c2", "Names": []string{"/web-worker-1"}, "Image": "sha256:0123",
+			"State": "exited", "Status": "Exited (137) 5 minutes ago",
+			"Labels": map[string]string{composeProjectLabel: "web", composeServiceLabel: "worker", composeOneoffLabel: "False"},
+		},
+		{
+			"Id": "c3", "Names": []string{"/db-db-1"}, "Image": "postgres:16",
+			"State": "exited", "Status": "Exited (0) 1 hour ago",
+			"Labels": map[string]string{composeProjectLabel: "db", composeServiceLabel: "db", composeOneoffLabel: "False"},
+		},
+	}
+
+	var filters [][]string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /containers/json", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("all") != "1" {
+			t.Errorf("Expected all=1, got %s", r.URL.RawQuery)
+		}
+		var filter struct {
+			Label []string `json:"label"`
+		}
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("filters")), &filter); err != nil {
+			t.Errorf("Invalid filters: %v", err)
+		}
+		filters = append(filters, filter.Label)
+
+		var matched []map[string]any
+		for _, container := range containers {
+			labels := container["Labels"].(map[string]string)
+			if matchesLabels(labels, filter.Label) {
+				matched = append(matched, container)
+			}
+		}
+		writeJSON(t, w, matched)
+	})
+	mux.HandleFunc("GET /containers/{id}/json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, map[string]any{
+			"Image":  "sha256:" + r.PathValue("id"),
+			"Config": map[string]string{"Image": "worker:latest"},
+		})
+	})
+	mux.HandleFunc("GET /images/{ref...}", func(w http.ResponseWriter, r *http.Request) {
+		ref := strings.TrimSuffix(r.PathValue("ref"), "/json")
+		if ref == "missing:latest" {
+			w.WriteHeader(http.StatusNotFound)
+			writeJSON(t, w, map[string]string{"message": "No such image: missing:latest"})
+			return
+		}
+		writeJSON(t, w, map[string]string{"Id": "sha256:" + ref})
+	})
+
+	client := NewEngineClient(newFakeEngine(t, mux))
+	stack := &Stack{Name: "web", Dir: "/stacks/01-web"}
+
+	t.Run("services", func(t *testing.T) {
+		services, err := client.Services(t.Context(), stack)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		want := []ServiceState{
+			{
+				Service: "app", Container: "web-app-1", State: "running", Health: "healthy",
+				Status: "Up 2 hours (healthy)", Image: "nginx:latest",
+			},
+			{
+				Service: "worker", Container: "web-worker-1", State: "exited",
+				Status: "Exited (137) 5 minutes ago", Image: "worker:latest", ExitCode: 137,
+			},
+		}
+		if len(services) != len(want) {
+			t.Fatalf("Expected %d services, got %+v", len(want), services)
+		}
+		for i := range want {
+			if services[i] != want[i] {
+				t.Errorf("Service %d: got %+v, want %+v", i, services[i], want[i])
+			}
+		}
+		assertSliceEqual(t, filters[len(filters)-1], []string{composeProjectLabel + "=web", composeOneoffLabel + "=False"})
+	})
+
+	t.Run("has containers", func(t *testing.T) {
+		if exists, err := client.HasContainers(t.Context(), stack); err != nil || !exists {
+			t.Errorf("Expected containers for web, got %v, %v", exists, err)
+		}
+		if exists, err := client.HasContainers(t.Context(), &Stack{Name: "none"}); err != nil || exists {
+			t.Errorf("Expected no containers, got %v, %v", exists, err)
+		}
+	})
+
+	t.Run("projects", func(t *testing.T) {
+		projects, err := client.Projects(t.Context())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if projects["web"].Status != StackStatusDegraded || projects["web"].Containers["exited"] != 1 {
+			t.Errorf("Unexpected web project: %+v", projects["web"])
+		}
+		if projects["db"].Status != StackStatusStopped {
+			t.Errorf("Expected db stopped, got %+v", projects["db"])
+		}
+	})
+
+	t.Run("images", func(t *testing.T) {
+		if id, err := client.ContainerImage(t.Context(), "c1"); err != nil || id != "sha256:c1" {
+			t.Errorf("Unexpected container image: %q, %v", id, err)
+		}
+		if id, err := client.ImageID(t.Context(), "ghcr.io/org/app:1.2"); err != nil || id != "sha256:ghcr.io/org/app:1.2" {
+			t.Errorf("Unexpected image ID: %q, %v", id, err)
+		}
+		_, err := client.ImageID(t.Context(), "missing:latest")
+		if err == nil || !strings.Contains(err.Error(), "No such image") {
+			t.Errorf("Expected API error message, got: %v", err)
+		}
+	})
+
+	t.Run("selected by config", func(t *testing.T) {
+		compose := NewComposeClient(&MockDockerExecutor{}, newTestLogger(t), &Config{
+			QueryBackend: QueryBackendAPI,
+			DockerSocket: filepath.Join(t.TempDir(), "none.sock"),
+		})
+		if _, ok := compose.query.(*EngineClient); !ok {
+			t.Errorf("Expected engine client, got %T", compose.query)
+		}
+		if compose.HasContainers(t.Context(), stack) {
+			t.Error("Expected false when the socket is unavailable")
+		}
+	})
+}
+
+func TestParseContainerStatus(t *testing.T) {
+	tests := []struct {
+		status   string
+		health   string
+		exitCode int
+	}{
+		{"Up 2 hours", "", 0},
+		{"Up 2 hours (healthy)", "healthy", 0},
+		{"Up 3 seconds (health: starting)", "starting", 0},
+		{"Up 1 minute (unhealthy)", "unhealthy", 0},
+		{"Exited (137) 5 minutes ago", "", 137},
+		{"Created", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			health, exitCode := parseContainerStatus(tt.status)
+			if health != tt.health || exitCode != tt.exitCode {
+				t.Errorf("parseContainerStatus(%q) = %q, %d; want %q, %d",
+					tt.status, health, exitCode, tt.health, tt.exitCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// StacksFailedError reports the stacks that failed during a keep-going run.
+type StacksFailedError struct {
+	Failed []string
+}
+
+func (e *StacksFailedError) Error() string {
+	return fmt.Sprintf("%d stack(s) failed: %s", len(e.Failed), joinStrings(e.Failed, ", "))
+}
+
+// ErrInterrupted is returned when a run is interrupted before every stack
+// was attempted.
+var ErrInterrupted = errors.New("interrupted")
+
+// executeWithDuplicateCheck runs fn for stacks in the given order, one at a
+// time or in waves when parallelism is enabled. By default the run stops after
+// the first failure; in keep-going mode every stack whose dependencies
+// succeeded is attempted and a summary is printed at the end. The outcome of
+// each stack is recorded in the stack state as soon as it finishes, so an
+// aborted run keeps the outcome of the stacks it completed.
+//
+// Once the run is interrupted or ctx is done, the stacks in progress finish
+// and the remaining ones are skipped; a summary of what completed is printed
+// and ErrInterrupted returned.
+func (m *StackManager) executeWithDuplicateCheck(
+	ctx context.Context, action Action, stacks []*Stack, fn stackFunc,
+) error {
+	if err := CheckDuplicates(stacks); err != nil {
+		return err
+	}
+
+	waves := m.planExecution(stacks)
+	results := make([]*StackResult, 0, len(stacks))
+	var abortErr error
+	interrupted := false
+
+	for i, wave := range waves {
+		if m.config.Parallelism > 1 {
+			m.logger.Info("Processing wave %d/%d: %s", i+1, len(waves), joinStrings(stackNames(wave), ", "))
+		}
+
+		waveResults := make([]*StackResult, len(wave))
+		var runnable []int
+		for j, stack := range wave {
+			reason := m.skipReason(stack, results, abortErr)
+			if reason == nil && m.interrupted(ctx) {
+				reason = errors.New("run interrupted")
+				interrupted = true
+			}
+			if reason != nil {
+				waveResults[j] = &StackResult{Stack: stack, Status: ResultSkipped, Err: reason}
+				continue
+			}
+			runnable = append(runnable, j)
+		}
+
+		m.executeWave(ctx, action, wave, runnable, waveResults, fn)
+		results = append(results, waveResults...)
+
+		if abortErr == nil && !m.config.KeepGoing {
+			abortErr = waveError(waveResults)
+		}
+	}
+
+	m.results = results
+
+	if interrupted {
+		m.printSummary(results)
+		completed := 0
+		for _, result := range results {
+			if result.Status != ResultSkipped {
+				completed++
+			}
+		}
+		return fmt.Errorf("%w: %d of %d stack(s) completed", ErrInterrupted, completed, len(results))
+	}
+
+	if !m.config.KeepGoing {
+		return abortErr
+	}
+
+	m.printSummary(results)
+
+	var failed []string
+	for _, result := range results {
+		if result.Status == ResultFailed {
+			failed = append(failed, result.Stack.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return &StacksFailedError{Failed: failed}
+	}
+
+	return nil
+}
+
+// planExecution groups stacks into waves. Without parallelism every stack
+// forms its own wave.
+func (m *StackManager) planExecution(stacks []*Stack) [][]*Stack {
+	if m.config.Parallelism > 1 {
+		return planWaves(stacks)
+	}
+
+	waves := make([][]*Stack, len(stacks))
+	for i, stack := range stacks {
+		waves[i] = []*Stack{stack}
+	}
+	return waves
+}
+
+// skipReason returns why a stack must not be attempted, or nil if it can run.
+// Stacks related by a dependency to a stack that did not succeed are skipped.
+func (m *StackManager) skipReason(stack *Stack, results []*StackResult, abortErr error) error {
+	if abortErr != nil {
+		return errors.New("run aborted after an earlier failure")
+	}
+
+	for _, result := range results {
+		if result.Status == ResultSucceeded {
+			continue
+		}
+		if dependsOn(stack, result.Stack) || dependsOn(result.Stack, stack) {
+			return fmt.Errorf("related stack %s %s", result.Stack.Name, result.Status)
+		}
+	}
+
+	return nil
+}
+
+// executeWave runs fn for the stacks of a wave selected by runnable and stores
+// their results. Several stacks are run concurrently, limited by the
+// configured parallelism, with their output prefixed by the stack name.
+func (m *StackManager) executeWave(
+	ctx context.Context, action Action, wave []*Stack, runnable []int, results []*StackResult, fn stackFunc,
+) {
+	if len(runnable) == 1 {
+		results[runnable[0]] = m.runStack(ctx, action, wave[runnable[0]], nil, fn)
+		return
+	}
+
+	width := 0
+	for _, i := range runnable {
+		width = max(width, len(wave[i].Name))
+	}
+
+	var (
+		wg       sync.WaitGroup
+		outputMu sync.Mutex
+		slots    = make(chan struct{}, m.config.Parallelism)
+	)
+
+	for _, i := range runnable {
+		stack := wave[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			out := newPrefixWriter(os.Stdout, &outputMu, fmt.Sprintf("%-*s | ", width, stack.Name))
+			results[i] = m.runStack(ctx, action, stack, out, fn)
+			if err := out.Flush(); err != nil {
+				m.logger.Debug("Failed to flush output of stack %s: %v", stack.Name, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// runStack runs fn for a single stack, logs its outcome and records it in
+// the stack state as the outcome of action.
+func (m *StackManager) runStack(
+	ctx context.Context, action Action, stack *Stack, out io.Writer, fn stackFunc,
+) *StackResult {
+	start := time.Now()
+	err := fn(ctx, stack, out)
+
+	result := &StackResult{
+		Stack:    stack,
+		Status:   ResultSucceeded,
+		Duration: time.Since(start),
+	}
+	logger := m.stackLogger(stack).With(logKeyDuration, result.Duration)
+	if err != nil {
+		result.Status = ResultFailed
+		result.Err = err
+		logger.With(logKeyError, err.Error()).Error("Stack %s failed: %v", stack.Name, err)
+	} else {
+		logger.Info("Stack %s succeeded in %s", stack.Name, result.Duration.Round(time.Millisecond))
+	}
+
+	m.recordResults(action, []*StackResult{result})
+	return result
+}
+
+// waveError combines the errors of all failed stacks in a wave.
+func waveError(results []*StackResult) error {
+	var errs []error
+	for _, result := range results {
+		if result != nil && result.Status == ResultFailed {
+			errs = append(errs, result.Err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *StackManager) printSummary(results []*StackResult) {
+	fmt.Fprintln(m.out)
+	w := tabwriter.NewWriter(m.out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "STACK\tRESULT\tDURATION\tERROR")
+	fmt.Fprintln(w, "-----\t------\t--------\t-----")
+
+	for _, result := range results {
+		duration := "-"
+		if result.Status != ResultSkipped {
+			duration = result.Duration.Round(time.Millisecond).String()
+		}
+		message := ""
+		if result.Err != nil {
+			message = result.Err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.Stack.Name, result.Status, duration, message)
+	}
+
+	//nolint:errcheck // Flush error is non-critical for display purposes
+	w.Flush()
+}
@@ -0,0 +1,143 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingStackFunc returns a stackFunc that records called stacks and fails
+// for the stacks listed in failing.
+func recordingStackFunc(called *[]string, failing ...string) stackFunc {
+	var mu sync.Mutex
+	return func(_ context.Context, stack *Stack, _ io.Writer) error {
+		mu.Lock()
+		defer mu.Unlock()
+		*called = append(*called, stack.Name)
+		if sliceContains(failing, stack.Name) {
+			return errors.New("boom")
+		}
+		return nil
+	}
+}
+
+func newExecuteTestManager(t *testing.T, config *Config) (*StackManager, *bytes.Buffer) {
+	t.Helper()
+	manager, out := newTestManager(t, t.TempDir(), config, &MockDockerExecutor{})
+	manager.dryRun = true
+	return manager, out
+}
+
+func TestExecuteWithDuplicateCheck(t *testing.T) {
+	stacks := []*Stack{
+		{Name: "db", Dir: "/stacks/10-db"},
+		{Name: "app", Dir: "/stacks/20-app", DependsOn: []string{"db"}},
+		{Name: "proxy", Dir: "/stacks/30-proxy"},
+	}
+
+	t.Run("stops at first failure by default", func(t *testing.T) {
+		manager, out := newExecuteTestManager(t, &Config{})
+		var called []string
+
+		err := manager.executeWithDuplicateCheck(t.Context(), ActionStart, stacks, recordingStackFunc(&called, "db"))
+		if err == nil || err.Error() != "boom" {
+			t.Errorf("Expected original error, got: %v", err)
+		}
+		assertSliceEqual(t, called, []string{"db"})
+		if out.Len() != 0 {
+			t.Errorf("Expected no summary, got %q", out.String())
+		}
+	})
+
+	t.Run("keep-going attempts independent stacks", func(t *testing.T) {
+		manager, out := newExecuteTestManager(t, &Config{KeepGoing: true})
+		var called []string
+
+		err := manager.executeWithDuplicateCheck(t.Context(), ActionStart, stacks, recordingStackFunc(&called, "db"))
+		var failedErr *StacksFailedError
+		if !errors.As(err, &failedErr) {
+			t.Fatalf("Expected StacksFailedError, got: %v", err)
+		}
+		assertSliceEqual(t, failedErr.Failed, []string{"db"})
+		assertSliceEqual(t, called, []string{"db", "proxy"})
+
+		results := manager.Results()
+		if len(results) != 3 || results[1].Status != ResultSkipped {
+			t.Errorf("Expected app to be recorded as skipped, got %+v", results)
+		}
+
+		summary := out.String()
+		for _, want := range []string{"db", "failed", "app", "skipped", "proxy", "succeeded"} {
+			if !strings.Contains(summary, want) {
+				t.Errorf("Expected %q in summary, got:\n%s", want, summary)
+			}
+		}
+	})
+
+	t.Run("keep-going succeeds when nothing fails", func(t *testing.T) {
+		manager, _ := newExecuteTestManager(t, &Config{KeepGoing: true})
+		var called []string
+
+		err := manager.executeWithDuplicateCheck(t.Context(), ActionStart, stacks, recordingStackFunc(&called))
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		assertSliceEqual(t, called, []string{"db", "app", "proxy"})
+	})
+
+	t.Run("parallel wave finishes before aborting", func(t *testing.T) {
+		manager, _ := newExecuteTestManager(t, &Config{Parallelism: 2})
+		var called []string
+		waveStacks := []*Stack{
+			{Name: "db", Dir: "/stacks/10-db"},
+			{Name: "cache", Dir: "/stacks/10-cache"},
+			{Name: "app", Dir: "/stacks/20-app"},
+		}
+
+		err := manager.executeWithDuplicateCheck(t.Context(), ActionStart, waveStacks, recordingStackFunc(&called, "db"))
+		if err == nil {
+			t.Fatal("Expected error")
+		}
+		if len(called) != 2 || sliceContains(called, "app") {
+			t.Errorf("Expected only the first wave to run, got %v", called)
+		}
+	})
+
+	t.Run("interrupt skips remaining stacks", func(t *testing.T) {
+		manager, out := newExecuteTestManager(t, &Config{})
+		var called []string
+		record := recordingStackFunc(&called)
+		fn := func(ctx context.Context, stack *Stack, out io.Writer) error {
+			manager.Interrupt()
+			return record(ctx, stack, out)
+		}
+
+		err := manager.executeWithDuplicateCheck(t.Context(), ActionStart, stacks, fn)
+		if !errors.Is(err, ErrInterrupted) || !strings.Contains(err.Error(), "1 of 3 stack(s) completed") {
+			t.Errorf("Expected interrupted error, got: %v", err)
+		}
+		assertSliceEqual(t, called, []string{"db"})
+		if !strings.Contains(out.String(), "run interrupted") {
+			t.Errorf("Expected summary with skipped stacks, got:\n%s", out.String())
+		}
+	})
+
+	t.Run("cancelled context skips all stacks", func(t *testing.T) {
+		manager, _ := newExecuteTestManager(t, &Config{})
+		var called []string
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		err := manager.executeWithDuplicateCheck(ctx, ActionStart, stacks, recordingStackFunc(&called))
+		if !errors.Is(err, ErrInterrupted) {
+			t.Errorf("Expected interrupted error, got: %v", err)
+		}
+		if len(called) != 0 {
+			t.Errorf("Expected no stack to run, got %v", called)
+		}
+	})
+}
@@ -0,0 +1,202 @@
+package loader
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// SortByDependencies orders stacks so that every stack comes after the stacks
+// listed in its depends-on. Stacks without a dependency between them keep
+// their input (directory prefix) order. Dependencies on unknown stacks are
+// ignored; see UnknownDependencies.
+func SortByDependencies(stacks []*Stack) ([]*Stack, error) {
+	deps := resolveDependencies(stacks)
+
+	// Count unresolved dependencies of each stack
+	pending := make([]int, len(stacks))
+	dependents := make([][]int, len(stacks))
+	for i, stackDeps := range deps {
+		pending[i] = len(stackDeps)
+		for _, dep := range stackDeps {
+			dependents[dep] = append(dependents[dep], i)
+		}
+	}
+
+	sorted := make([]*Stack, 0, len(stacks))
+	done := make([]bool, len(stacks))
+
+	for len(sorted) < len(stacks) {
+		// Pick the first stack in prefix order whose dependencies are all sorted
+		next := -1
+		for i := range stacks {
+			if !done[i] && pending[i] == 0 {
+				next = i
+				break
+			}
+		}
+
+		if next == -1 {
+			return nil, fmt.Errorf("dependency cycle detected: %s", describeCycle(stacks, deps, done))
+		}
+
+		done[next] = true
+		sorted = append(sorted, stacks[next])
+		for _, dependent := range dependents[next] {
+			pending[dependent]--
+		}
+	}
+
+	return sorted, nil
+}
+
+// UnknownDependencies describes every depends-on entry that does not name
+// one of stacks.
+func UnknownDependencies(stacks []*Stack) []string {
+	index := dependencyIndex(stacks)
+
+	var unknown []string
+	for _, stack := range stacks {
+		for _, name := range stack.DependsOn {
+			if _, ok := index[name]; !ok {
+				unknown = append(unknown, fmt.Sprintf("stack %q depends on unknown stack %q", stack.Name, name))
+			}
+		}
+	}
+	return unknown
+}
+
+// dependencyIndex maps the names and directory names of stacks to their
+// indexes, the names a dependency may reference a stack by.
+func dependencyIndex(stacks []*Stack) map[string][]int {
+	index := make(map[string][]int)
+	for i, stack := range stacks {
+		index[stack.Name] = append(index[stack.Name], i)
+		dirName := filepath.Base(stack.Dir)
+		if dirName != stack.Name {
+			index[dirName] = append(index[dirName], i)
+		}
+	}
+	return index
+}
+
+// resolveDependencies maps each stack's depends-on entries to indexes in
+// stacks, skipping entries that do not name a stack.
+func resolveDependencies(stacks []*Stack) [][]int {
+	index := dependencyIndex(stacks)
+
+	deps := make([][]int, len(stacks))
+	for i, stack := range stacks {
+		seen := make(map[int]bool)
+		for _, name := range stack.DependsOn {
+			for _, target := range index[name] {
+				if !seen[target] {
+					seen[target] = true
+					deps[i] = append(deps[i], target)
+				}
+			}
+		}
+	}
+
+	return deps
+}
+
+// describeCycle returns a human-readable cycle among the stacks not yet sorted,
+// e.g. "web -> api -> web".
+func describeCycle(stacks []*Stack, deps [][]int, done []bool) string {
+	start := -1
+	for i := range stacks {
+		if !done[i] {
+			start = i
+			break
+		}
+	}
+
+	// Every unsorted stack has an unsorted dependency, so following them
+	// from any unsorted stack must eventually revisit a stack.
+	position := make(map[int]int)
+	var path []int
+	current := start
+	for {
+		if pos, ok := position[current]; ok {
+			path = append(path[pos:], current)
+			break
+		}
+		position[current] = len(path)
+		path = append(path, current)
+
+		for _, dep := range deps[current] {
+			if !done[dep] {
+				current = dep
+				break
+			}
+		}
+	}
+
+	names := make([]string, len(path))
+	for i, idx := range path {
+		names[i] = stacks[idx].Name
+	}
+	return joinStrings(names, " -> ")
+}
+
+// planWaves splits ordered stacks into waves that can run concurrently.
+// Consecutive stacks sharing the same numeric prefix form a wave unless one of
+// them depends on another, in which case the dependent starts a new wave.
+// The order of waves and of stacks within each wave is preserved.
+func planWaves(stacks []*Stack) [][]*Stack {
+	var waves [][]*Stack
+	var current []*Stack
+	currentOrder := ""
+
+	for _, stack := range stacks {
+		order := stackOrder(stack)
+		if len(current) > 0 && (order != currentOrder || dependsOnAny(stack, current)) {
+			waves = append(waves, current)
+			current = nil
+		}
+		if len(current) == 0 {
+			currentOrder = order
+		}
+		current = append(current, stack)
+	}
+
+	if len(current) > 0 {
+		waves = append(waves, current)
+	}
+
+	return waves
+}
+
+// dependsOnAny reports whether stack and any of others depend on each other.
+func dependsOnAny(stack *Stack, others []*Stack) bool {
+	for _, other := range others {
+		if dependsOn(stack, other) || dependsOn(other, stack) {
+			return true
+		}
+	}
+	return false
+}
+
+func dependsOn(stack, target *Stack) bool {
+	for _, name := range stack.DependsOn {
+		if name == target.Name || name == filepath.Base(target.Dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// stackOrder returns the numeric prefix of the stack directory.
+// Format: NN-stack-name -> NN
+func stackOrder(stack *Stack) string {
+	return strings.SplitN(filepath.Base(stack.Dir), "-", 2)[0]
+}
+
+func stackNames(stacks []*Stack) []string {
+	names := make([]string, len(stacks))
+	for i, stack := range stacks {
+		names[i] = stack.Name
+	}
+	return names
+}
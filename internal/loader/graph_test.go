@@ -0,0 +1,142 @@
+package loader
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSortByDependencies(t *testing.T) {
+	t.Run("keeps prefix order without dependencies", func(t *testing.T) {
+		stacks := []*Stack{
+			{Name: "proxy", Dir: "/stacks/10-proxy"},
+			{Name: "db", Dir: "/stacks/20-db"},
+			{Name: "app", Dir: "/stacks/30-app"},
+		}
+
+		sorted, err := SortByDependencies(stacks)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		assertSliceEqual(t, stackNames(sorted), []string{"proxy", "db", "app"})
+	})
+
+	t.Run("moves dependencies before dependents", func(t *testing.T) {
+		stacks := []*Stack{
+			{Name: "app", Dir: "/stacks/10-app", DependsOn: []string{"db"}},
+			{Name: "proxy", Dir: "/stacks/20-proxy"},
+			{Name: "db", Dir: "/stacks/30-db"},
+		}
+
+		sorted, err := SortByDependencies(stacks)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		assertSliceEqual(t, stackNames(sorted), []string{"proxy", "db", "app"})
+	})
+
+	t.Run("resolves dependencies by directory name", func(t *testing.T) {
+		stacks := []*Stack{
+			{Name: "app", Dir: "/stacks/10-app", DependsOn: []string{"20-db"}},
+			{Name: "db", Dir: "/stacks/20-db"},
+		}
+
+		sorted, err := SortByDependencies(stacks)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		assertSliceEqual(t, stackNames(sorted), []string{"db", "app"})
+	})
+
+	t.Run("unknown dependency is ignored", func(t *testing.T) {
+		stacks := []*Stack{
+			{Name: "app", Dir: "/stacks/10-app", DependsOn: []string{"missing", "db"}},
+			{Name: "db", Dir: "/stacks/20-db"},
+		}
+
+		sorted, err := SortByDependencies(stacks)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		assertSliceEqual(t, stackNames(sorted), []string{"db", "app"})
+	})
+
+	t.Run("cycle returns error naming the stacks", func(t *testing.T) {
+		stacks := []*Stack{
+			{Name: "proxy", Dir: "/stacks/05-proxy"},
+			{Name: "app", Dir: "/stacks/10-app", DependsOn: []string{"api"}},
+			{Name: "api", Dir: "/stacks/20-api", DependsOn: []string{"app"}},
+		}
+
+		_, err := SortByDependencies(stacks)
+		if err == nil {
+			t.Fatal("Expected error for dependency cycle")
+		}
+		if !strings.Contains(err.Error(), "app -> api -> app") {
+			t.Errorf("Expected cycle path in error, got: %s", err.Error())
+		}
+	})
+
+	t.Run("self dependency is a cycle", func(t *testing.T) {
+		stacks := []*Stack{
+			{Name: "app", Dir: "/stacks/10-app", DependsOn: []string{"app"}},
+		}
+
+		if _, err := SortByDependencies(stacks); err == nil {
+			t.Fatal("Expected error for self dependency")
+		}
+	})
+}
+
+func TestPlanWaves(t *testing.T) {
+	waveNames := func(waves [][]*Stack) []string {
+		result := make([]string, len(waves))
+		for i, wave := range waves {
+			result[i] = strings.Join(stackNames(wave), ",")
+		}
+		return result
+	}
+
+	t.Run("groups stacks by order prefix", func(t *testing.T) {
+		stacks := []*Stack{
+			{Name: "proxy", Dir: "/stacks/10-proxy"},
+			{Name: "db", Dir: "/stacks/20-db"},
+			{Name: "cache", Dir: "/stacks/20-cache"},
+			{Name: "app", Dir: "/stacks/30-app"},
+		}
+		assertSliceEqual(t, waveNames(planWaves(stacks)), []string{"proxy", "db,cache", "app"})
+	})
+
+	t.Run("splits wave on dependency within prefix", func(t *testing.T) {
+		stacks := []*Stack{
+			{Name: "db", Dir: "/stacks/20-db"},
+			{Name: "cache", Dir: "/stacks/20-cache"},
+			{Name: "api", Dir: "/stacks/20-api", DependsOn: []string{"db"}},
+		}
+		assertSliceEqual(t, waveNames(planWaves(stacks)), []string{"db,cache", "api"})
+	})
+
+	t.Run("splits reversed order on dependents", func(t *testing.T) {
+		stacks := []*Stack{
+			{Name: "api", Dir: "/stacks/20-api", DependsOn: []string{"db"}},
+			{Name: "cache", Dir: "/stacks/20-cache"},
+			{Name: "db", Dir: "/stacks/20-db"},
+		}
+		assertSliceEqual(t, waveNames(planWaves(stacks)), []string{"api,cache", "db"})
+	})
+
+	t.Run("empty input has no waves", func(t *testing.T) {
+		if waves := planWaves(nil); len(waves) != 0 {
+			t.Errorf("Expected no waves, got %d", len(waves))
+		}
+	})
+}
+
+func TestUnknownDependencies(t *testing.T) {
+	stacks := []*Stack{
+		{Name: "app", Dir: "/stacks/10-app", DependsOn: []string{"missing", "20-db"}},
+		{Name: "db", Dir: "/stacks/20-db", DependsOn: []string{"app"}},
+	}
+
+	unknown := UnknownDependencies(stacks)
+	assertSliceEqual(t, unknown, []string{`stack "app" depends on unknown stack "missing"`})
+}
@@ -0,0 +1,93 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultHealthInterval is the delay between service health polls.
+const defaultHealthInterval = 2 * time.Second
+
+// waitHealthy polls the services of a stack until all of them are running and
+// healthy, or returns an error describing each service once timeout expires.
+func (m *StackManager) waitHealthy(
+	ctx context.Context, stack *Stack, compose *ComposeClient, timeout time.Duration,
+) error {
+	logger := m.stackLogger(stack)
+	logger.Info("Waiting up to %s for stack %s to become healthy", timeout, stack.Name)
+	deadline := time.Now().Add(timeout)
+
+	for {
+		services, err := compose.Services(ctx, stack)
+		if err == nil && servicesReady(services) {
+			logger.Info("Stack %s is healthy", stack.Name)
+			return nil
+		}
+
+		if !time.Now().Before(deadline) {
+			if err != nil {
+				return fmt.Errorf("stack %s not healthy after %s: %w", stack.Name, timeout, err)
+			}
+			return fmt.Errorf("stack %s not healthy after %s:\n%s", stack.Name, timeout, describeServices(services))
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for stack %s to become healthy: %w", stack.Name, ctx.Err())
+		case <-time.After(m.healthInterval):
+		}
+	}
+}
+
+// servicesReady reports whether every service is running and, if it defines
+// a health check, healthy. Services that exited successfully count as ready,
+// which covers one-shot containers such as migrations.
+func servicesReady(services []ServiceState) bool {
+	if len(services) == 0 {
+		return false
+	}
+
+	for _, service := range services {
+		if !serviceReady(service) {
+			return false
+		}
+	}
+	return true
+}
+
+func serviceReady(service ServiceState) bool {
+	switch service.State {
+	case "running":
+		return service.Health == "" || service.Health == healthHealthy
+	case "exited":
+		return service.ExitCode == 0
+	default:
+		return false
+	}
+}
+
+// describeServices renders one line per service for timeout reports.
+// Format: "  db: running (health: starting)"
+func describeServices(services []ServiceState) string {
+	if len(services) == 0 {
+		return "  no containers found"
+	}
+
+	lines := make([]string, 0, len(services))
+	for _, service := range services {
+		line := fmt.Sprintf("  %s: %s", service.Service, service.State)
+		switch {
+		case service.Health != "":
+			line += fmt.Sprintf(" (health: %s)", service.Health)
+		case service.State == "exited":
+			line += fmt.Sprintf(" (exit code: %d)", service.ExitCode)
+		}
+		if !serviceReady(service) {
+			line += " [not ready]"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,71 @@
+package loader
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServicesReady(t *testing.T) {
+	tests := []struct {
+		name     string
+		services []ServiceState
+		expected bool
+	}{
+		{"no services", nil, false},
+		{"running without health check", []ServiceState{{State: "running"}}, true},
+		{"running and healthy", []ServiceState{{State: "running", Health: "healthy"}}, true},
+		{"health starting", []ServiceState{{State: "running", Health: "starting"}}, false},
+		{"exited successfully", []ServiceState{{State: "running"}, {State: "exited"}}, true},
+		{"exited with error", []ServiceState{{State: "exited", ExitCode: 1}}, false},
+		{"restarting", []ServiceState{{State: "restarting"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := servicesReady(tt.services); result != tt.expected {
+				t.Errorf("servicesReady() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWaitHealthy(t *testing.T) {
+	stack := &Stack{Name: "db", Dir: "/stacks/10-db"}
+
+	t.Run("returns when services are healthy", func(t *testing.T) {
+		manager := NewStackManager(t.TempDir(), &Config{}, newTestLogger(t), false)
+		mock := &MockDockerExecutor{RunQuietOut: []byte(`[{"Service":"db","State":"running","Health":"healthy"}]`)}
+		compose := NewComposeClient(mock, newTestLogger(t), &Config{})
+
+		if err := manager.waitHealthy(t.Context(), stack, compose, time.Second); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if len(mock.RunQuietCalls) != 1 {
+			t.Errorf("Expected 1 poll, got %d", len(mock.RunQuietCalls))
+		}
+	})
+
+	t.Run("reports services on timeout", func(t *testing.T) {
+		manager := NewStackManager(t.TempDir(), &Config{}, newTestLogger(t), false)
+		manager.healthInterval = time.Millisecond
+		mock := &MockDockerExecutor{RunQuietOut: []byte(
+			`[{"Service":"db","State":"running","Health":"starting"},{"Service":"app","State":"running"}]`,
+		)}
+		compose := NewComposeClient(mock, newTestLogger(t), &Config{})
+
+		err := manager.waitHealthy(t.Context(), stack, compose, 10*time.Millisecond)
+		if err == nil {
+			t.Fatal("Expected timeout error")
+		}
+		if !strings.Contains(err.Error(), "db: running (health: starting) [not ready]") {
+			t.Errorf("Expected per-service report, got: %s", err.Error())
+		}
+		if strings.Contains(err.Error(), "app: running [not ready]") {
+			t.Errorf("Ready service reported as not ready: %s", err.Error())
+		}
+		if len(mock.RunQuietCalls) < 2 {
+			t.Errorf("Expected repeated polling, got %d calls", len(mock.RunQuietCalls))
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package loader
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// showHistory prints the recorded actions of a single stack, newest first.
+func (m *StackManager) showHistory(stacks []*Stack) error {
+	stack, err := singleStack(ActionHistory, stacks)
+	if err != nil {
+		return err
+	}
+
+	history, err := m.state.History(stack.Name)
+	if err != nil {
+		return err
+	}
+	slices.Reverse(history)
+
+	switch m.format {
+	case OutputJSON, OutputYAML:
+		if history == nil {
+			history = []ActionRecord{}
+		}
+		return encodeOutput(m.out, m.format, history)
+	case OutputTable, OutputWide, "":
+		if len(history) == 0 {
+			fmt.Fprintf(m.out, "No recorded actions for stack %s\n", stack.Name)
+			return nil
+		}
+		writeHistoryTable(m.out, history)
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format: %s", m.format)
+	}
+}
+
+func writeHistoryTable(out io.Writer, history []ActionRecord) {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "TIME\tACTION\tRESULT\tDURATION\tUSER\tVERSION\tERROR")
+	fmt.Fprintln(w, "----\t------\t------\t--------\t----\t-------\t-----")
+
+	for _, record := range history {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", record.Time.Local().Format(time.DateTime), record.Action,
+			record.Status, record.Duration.Round(time.Second), orDash(record.User), orDash(record.Version),
+			orDash(firstLine(record.Error)))
+	}
+
+	//nolint:errcheck // Flush error is non-critical for display purposes
+	w.Flush()
+}
+
+// singleStack returns the only stack of stacks, or an error naming the
+// action if several stacks are selected.
+func singleStack(action Action, stacks []*Stack) (*Stack, error) {
+	if len(stacks) > 1 {
+		return nil, fmt.Errorf("%s requires a single stack, but %d match: %s",
+			action, len(stacks), strings.Join(stackNames(stacks), ", "))
+	}
+	return stacks[0], nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// firstLine returns the first line of a possibly multi-line message.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
@@ -0,0 +1,207 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// Run results reported to after-all hooks.
+const (
+	RunResultSuccess = "success"
+	RunResultFailure = "failure"
+)
+
+// RunEvent describes a whole composectl run for global hooks.
+type RunEvent struct {
+	Action       string   `json:"action"`
+	Result       string   `json:"result,omitempty"`
+	Error        string   `json:"error,omitempty"`
+	Stacks       []string `json:"stacks"`
+	FailedStacks []string `json:"failed_stacks"`
+}
+
+// NewRunEvent creates an event for an action about to run on stacks.
+func NewRunEvent(action string, stacks []*Stack) *RunEvent {
+	return &RunEvent{
+		Action:       action,
+		Stacks:       stackNames(stacks),
+		FailedStacks: []string{},
+	}
+}
+
+// Complete records the outcome of the run.
+func (e *RunEvent) Complete(results []*StackResult, err error) {
+	e.Result = RunResultSuccess
+	if err != nil {
+		e.Result = RunResultFailure
+		e.Error = err.Error()
+	}
+
+	for _, result := range results {
+		if result.Status == ResultFailed {
+			e.FailedStacks = append(e.FailedStacks, result.Stack.Name)
+		}
+	}
+}
+
+// Env returns environment variables describing the event.
+func (e *RunEvent) Env() []string {
+	return []string{
+		"COMPOSECTL_ACTION=" + e.Action,
+		"COMPOSECTL_STACKS=" + strings.Join(e.Stacks, " "),
+		"COMPOSECTL_RESULT=" + e.Result,
+		"COMPOSECTL_FAILED_STACKS=" + strings.Join(e.FailedStacks, " "),
+		"COMPOSECTL_ERROR=" + e.Error,
+	}
+}
+
+// HookRunner executes lifecycle hooks.
+type HookRunner struct {
+	logger *Logger
+	dryRun bool
+}
+
+// NewHookRunner creates a new hook runner.
+func NewHookRunner(logger *Logger, dryRun bool) *HookRunner {
+	return &HookRunner{
+		logger: logger,
+		dryRun: dryRun,
+	}
+}
+
+// WithLogger returns a copy of the runner writing its entries to logger.
+func (r *HookRunner) WithLogger(logger *Logger) *HookRunner {
+	return &HookRunner{logger: logger, dryRun: r.dryRun}
+}
+
+// Run executes hooks in order from dir with env added to the environment.
+// Failing hooks with the warn policy are logged; the first failing hook with
+// the abort policy stops execution and its error is returned. When out is nil,
+// hook output goes to the terminal.
+func (r *HookRunner) Run(
+	ctx context.Context, name string, hooks []Hook, dir string, env []string, out io.Writer,
+) error {
+	return r.run(ctx, name, hooks, dir, env, nil, out)
+}
+
+// RunGlobal executes run-level hooks from dir. The event is passed both as
+// environment variables and as JSON on stdin.
+func (r *HookRunner) RunGlobal(ctx context.Context, name string, hooks []Hook, dir string, event *RunEvent) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encoding %s hook input: %w", name, err)
+	}
+
+	env := append(event.Env(), "COMPOSECTL_BASE_DIR="+dir)
+	return r.run(ctx, name, hooks, dir, env, payload, nil)
+}
+
+func (r *HookRunner) run(
+	ctx context.Context, name string, hooks []Hook, dir string, env []string, stdin []byte, out io.Writer,
+) error {
+	for i := range hooks {
+		hook := &hooks[i]
+		err := r.runHook(ctx, name, hook, dir, env, stdin, out)
+		if err == nil {
+			continue
+		}
+
+		if hook.OnFailure == HookFailureWarn {
+			r.logger.Warning("%s hook failed, continuing: %v", name, err)
+			continue
+		}
+		return fmt.Errorf("%s hook failed: %w", name, err)
+	}
+
+	return nil
+}
+
+func (r *HookRunner) runHook(
+	ctx context.Context, name string, hook *Hook, dir string, env []string, stdin []byte, out io.Writer,
+) error {
+	cmd, description, err := hookCommand(hook, dir)
+	if err != nil {
+		return err
+	}
+
+	if r.dryRun {
+		r.logger.Info("[DRY-RUN] Would run %s hook: %s", name, description)
+		return nil
+	}
+
+	r.logger.Info("Running %s hook: %s", name, description)
+
+	ctx, cancel := context.WithTimeout(ctx, hook.TimeoutDuration())
+	defer cancel()
+
+	//nolint:gosec // Hooks are defined by the administrator in trusted config files
+	execCmd := exec.CommandContext(ctx, cmd[0], cmd[1:]...)
+	execCmd.Dir = dir
+	// Run in a separate process group so a timeout also kills any children
+	execCmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	execCmd.Cancel = func() error {
+		return syscall.Kill(-execCmd.Process.Pid, syscall.SIGKILL)
+	}
+	execCmd.Env = append(append(os.Environ(), env...), "COMPOSECTL_HOOK="+name)
+	if stdin != nil {
+		execCmd.Stdin = bytes.NewReader(stdin)
+	}
+	if out != nil {
+		execCmd.Stdout = out
+		execCmd.Stderr = out
+	} else {
+		execCmd.Stdout = os.Stdout
+		execCmd.Stderr = os.Stderr
+	}
+
+	if err := execCmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%s: timed out after %s", description, hook.TimeoutDuration())
+		}
+		return fmt.Errorf("%s: %w", description, err)
+	}
+
+	return nil
+}
+
+// hookCommand returns the argv and a description of a hook. Scripts are
+// resolved relative to dir; commands run through sh -c.
+func hookCommand(hook *Hook, dir string) (argv []string, description string, err error) {
+	switch {
+	case hook.Command != "" && hook.Script != "":
+		return nil, "", errors.New("hook must define either command or script, not both")
+	case hook.Command != "":
+		return []string{"sh", "-c", hook.Command}, hook.Command, nil
+	case hook.Script != "":
+		script := hook.Script
+		if !filepath.IsAbs(script) {
+			script = filepath.Join(dir, script)
+		}
+		return []string{script}, script, nil
+	default:
+		return nil, "", errors.New("hook must define command or script")
+	}
+}
+
+// stackHookEnv returns environment variables describing a stack operation.
+func stackHookEnv(baseDir string, stack *Stack, action Action) []string {
+	return []string{
+		"COMPOSECTL_BASE_DIR=" + baseDir,
+		"COMPOSECTL_STACK=" + stack.Name,
+		"COMPOSECTL_STACK_DIR=" + stack.Dir,
+		"COMPOSECTL_ACTION=" + string(action),
+	}
+}
@@ -0,0 +1,236 @@
+package loader
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHookRunner(t *testing.T) {
+	t.Run("runs command in directory with environment", func(t *testing.T) {
+		dir := t.TempDir()
+		runner := NewHookRunner(newTestLogger(t), false)
+		hooks := []Hook{{Command: `echo "$COMPOSECTL_STACK $COMPOSECTL_HOOK $EXTRA" > out.txt`}}
+
+		env := []string{"COMPOSECTL_STACK=web", "EXTRA=1"}
+		if err := runner.Run(t.Context(), "pre-start", hooks, dir, env, nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+		if err != nil {
+			t.Fatalf("Hook did not run in stack directory: %v", err)
+		}
+		if strings.TrimSpace(string(content)) != "web pre-start 1" {
+			t.Errorf("Unexpected hook output: %q", content)
+		}
+	})
+
+	t.Run("runs script relative to directory", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, "hook.sh", "#!/bin/sh\ntouch ran\n")
+		if err := os.Chmod(filepath.Join(dir, "hook.sh"), 0o755); err != nil {
+			t.Fatalf("Failed to chmod script: %v", err)
+		}
+		runner := NewHookRunner(newTestLogger(t), false)
+
+		if err := runner.Run(t.Context(), "post-start", []Hook{{Script: "hook.sh"}}, dir, nil, nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "ran")); err != nil {
+			t.Error("Script was not executed")
+		}
+	})
+
+	t.Run("abort policy returns error and stops", func(t *testing.T) {
+		dir := t.TempDir()
+		runner := NewHookRunner(newTestLogger(t), false)
+		hooks := []Hook{{Command: "exit 3"}, {Command: "touch second"}}
+
+		err := runner.Run(t.Context(), "pre-stop", hooks, dir, nil, nil)
+		if err == nil {
+			t.Fatal("Expected error")
+		}
+		if !strings.Contains(err.Error(), "pre-stop hook failed") {
+			t.Errorf("Expected hook name in error, got: %s", err.Error())
+		}
+		if _, err := os.Stat(filepath.Join(dir, "second")); err == nil {
+			t.Error("Hooks after an aborting failure should not run")
+		}
+	})
+
+	t.Run("warn policy continues", func(t *testing.T) {
+		dir := t.TempDir()
+		runner := NewHookRunner(newTestLogger(t), false)
+		hooks := []Hook{{Command: "exit 1", OnFailure: HookFailureWarn}, {Command: "touch second"}}
+
+		if err := runner.Run(t.Context(), "post-stop", hooks, dir, nil, nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "second")); err != nil {
+			t.Error("Hooks after a warning failure should run")
+		}
+	})
+
+	t.Run("timeout fails hook", func(t *testing.T) {
+		runner := NewHookRunner(newTestLogger(t), false)
+		hooks := []Hook{{Command: "sleep 5", Timeout: 1}}
+
+		err := runner.Run(t.Context(), "pre-start", hooks, t.TempDir(), nil, nil)
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Errorf("Expected timeout error, got: %v", err)
+		}
+	})
+
+	t.Run("dry run does not execute", func(t *testing.T) {
+		dir := t.TempDir()
+		runner := NewHookRunner(newTestLogger(t), true)
+
+		if err := runner.Run(t.Context(), "pre-start", []Hook{{Command: "touch ran"}}, dir, nil, nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "ran")); err == nil {
+			t.Error("Dry run should not execute hooks")
+		}
+	})
+
+	t.Run("invalid hook returns error", func(t *testing.T) {
+		runner := NewHookRunner(newTestLogger(t), false)
+		if err := runner.Run(t.Context(), "pre-start", []Hook{{}}, t.TempDir(), nil, nil); err == nil {
+			t.Error("Expected error for empty hook")
+		}
+	})
+}
+
+func TestHookRunnerRunGlobal(t *testing.T) {
+	t.Run("passes event as env and json stdin", func(t *testing.T) {
+		dir := t.TempDir()
+		runner := NewHookRunner(newTestLogger(t), false)
+		hooks := []Hook{{Command: `echo "$COMPOSECTL_RESULT|$COMPOSECTL_FAILED_STACKS" > env.txt; cat > event.json`}}
+
+		event := NewRunEvent("start", []*Stack{{Name: "web"}, {Name: "db"}})
+		event.Complete([]*StackResult{
+			{Stack: &Stack{Name: "web"}, Status: ResultSucceeded},
+			{Stack: &Stack{Name: "db"}, Status: ResultFailed, Err: errors.New("boom")},
+		}, errors.New("1 stack(s) failed: db"))
+
+		if err := runner.RunGlobal(t.Context(), "after-all", hooks, dir, event); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		env, err := os.ReadFile(filepath.Join(dir, "env.txt"))
+		if err != nil {
+			t.Fatalf("Hook did not run: %v", err)
+		}
+		if strings.TrimSpace(string(env)) != "failure|db" {
+			t.Errorf("Unexpected environment: %q", env)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, "event.json"))
+		if err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		var decoded RunEvent
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Invalid event JSON %q: %v", data, err)
+		}
+		assertSliceEqual(t, decoded.Stacks, []string{"web", "db"})
+		assertSliceEqual(t, decoded.FailedStacks, []string{"db"})
+		if decoded.Action != "start" || decoded.Result != RunResultFailure {
+			t.Errorf("Unexpected event: %+v", decoded)
+		}
+	})
+
+	t.Run("no hooks is a no-op", func(t *testing.T) {
+		runner := NewHookRunner(newTestLogger(t), false)
+		if err := runner.RunGlobal(t.Context(), "before-all", nil, t.TempDir(), NewRunEvent("stop", nil)); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+}
+
+func TestRunEventComplete(t *testing.T) {
+	event := NewRunEvent("stop", []*Stack{{Name: "web"}})
+	event.Complete([]*StackResult{{Stack: &Stack{Name: "web"}, Status: ResultSucceeded}}, nil)
+
+	if event.Result != RunResultSuccess || event.Error != "" || len(event.FailedStacks) != 0 {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+	if !sliceContains(event.Env(), "COMPOSECTL_STACKS=web") {
+		t.Errorf("Expected stacks in environment, got %v", event.Env())
+	}
+}
+
+func TestStackManagerHooks(t *testing.T) {
+	t.Run("failing pre-start hook skips compose", func(t *testing.T) {
+		dir := t.TempDir()
+		stackDir := filepath.Join(dir, "stacks", "10-web")
+		mustMkdir(t, stackDir)
+		writeFile(t, stackDir, "config.yaml", "hooks:\n  pre-start:\n    - exit 1\n")
+
+		mock := &MockDockerExecutor{RunQuietOut: []byte("[]")}
+		manager, _ := newTestManager(t, dir, nil, mock)
+
+		if err := performAction(t, manager, "start", "web"); err == nil {
+			t.Fatal("Expected error from pre-start hook")
+		}
+		if len(mock.RunCalls) != 0 {
+			t.Errorf("Expected no compose calls, got %v", mock.RunCalls)
+		}
+	})
+
+	t.Run("invalid config skips hooks with an error", func(t *testing.T) {
+		dir := t.TempDir()
+		stackDir := filepath.Join(dir, "stacks", "10-web")
+		mustMkdir(t, stackDir)
+		writeFile(t, stackDir, "config.yaml",
+			"hooks:\n  pre-stop:\n    - command: touch hook.txt\n      on-failure: ignore\n")
+
+		mock := &MockDockerExecutor{RunQuietOut: []byte("[]")}
+		manager, _ := newTestManager(t, dir, nil, mock)
+
+		for _, action := range []string{"stop", "down"} {
+			if err := performAction(t, manager, action, "web"); err != nil {
+				t.Fatalf("Unexpected error for %s: %v", action, err)
+			}
+		}
+		if len(mock.RunCalls) != 2 {
+			t.Errorf("Expected stop and down compose calls, got %v", mock.RunCalls)
+		}
+		if _, err := os.Stat(filepath.Join(stackDir, "hook.txt")); err == nil {
+			t.Error("Expected hook of invalid config to be skipped")
+		}
+
+		content, err := os.ReadFile(manager.logger.file.path)
+		if err != nil {
+			t.Fatalf("Failed to read log: %v", err)
+		}
+		if !strings.Contains(string(content), "ERROR: Failed to load config of stack web, using defaults without hooks") {
+			t.Errorf("Expected logged error, got:\n%s", content)
+		}
+	})
+
+	t.Run("post-stop hook runs after down", func(t *testing.T) {
+		dir := t.TempDir()
+		stackDir := filepath.Join(dir, "stacks", "10-web")
+		mustMkdir(t, stackDir)
+		writeFile(t, stackDir, "config.yaml", "hooks:\n  post-stop:\n    - echo $COMPOSECTL_ACTION > action.txt\n")
+
+		mock := &MockDockerExecutor{RunQuietOut: []byte("[]")}
+		manager, _ := newTestManager(t, dir, nil, mock)
+
+		if err := performAction(t, manager, "down", "web"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		content, err := os.ReadFile(filepath.Join(stackDir, "action.txt"))
+		if err != nil {
+			t.Fatalf("post-stop hook did not run: %v", err)
+		}
+		if strings.TrimSpace(string(content)) != "down" {
+			t.Errorf("Expected action 'down', got %q", content)
+		}
+	})
+}
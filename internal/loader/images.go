@@ -0,0 +1,95 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// stateDir is the directory below the base directory where composectl keeps
+// its state.
+const stateDir = ".composectl"
+
+// ServiceImage identifies the image a service ran.
+type ServiceImage struct {
+	Service string `json:"service"`
+	// Image is the image reference from the compose file, e.g. nginx:latest
+	Image string `json:"image"`
+	// ID is the image ID the reference resolved to
+	ID string `json:"id"`
+}
+
+// ImageRecord lists the images of a stack's services before its last update.
+type ImageRecord struct {
+	Time     time.Time      `json:"time"`
+	Services []ServiceImage `json:"services"`
+}
+
+// newImageRecord records the images the updated services ran before the update.
+func newImageRecord(updates []ImageUpdate) *ImageRecord {
+	record := &ImageRecord{Time: time.Now()}
+	for _, update := range updates {
+		record.Services = append(record.Services, ServiceImage{
+			Service: update.Service,
+			Image:   update.Image,
+			ID:      update.Current,
+		})
+	}
+	return record
+}
+
+// ImageStore persists the image records of stacks in a JSON file.
+// Updates from concurrent composectl processes are serialized with a file lock.
+type ImageStore struct {
+	path string
+}
+
+// NewImageStore creates an image store in the state directory of baseDir.
+func NewImageStore(baseDir string) *ImageStore {
+	return &ImageStore{path: filepath.Join(baseDir, stateDir, "images.json")}
+}
+
+// Load returns the image record of a stack, or nil if there is none.
+func (s *ImageStore) Load(stack string) (*ImageRecord, error) {
+	records, err := readJSONFile[map[string]*ImageRecord](s.path)
+	if err != nil {
+		return nil, err
+	}
+	return records[stack], nil
+}
+
+// Save replaces the image record of a stack.
+func (s *ImageStore) Save(stack string, record *ImageRecord) error {
+	return withFileLock(s.path, func() error {
+		records, err := readJSONFile[map[string]*ImageRecord](s.path)
+		if err != nil {
+			return err
+		}
+		if records == nil {
+			records = make(map[string]*ImageRecord)
+		}
+		records[stack] = record
+
+		return writeJSONFile(s.path, records)
+	})
+}
+
+// writeJSONFile atomically replaces path with the JSON encoding of v.
+// The directory of path must exist.
+func writeJSONFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", filepath.Base(path), err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil { //nolint:gosec // State file is not secret
+		return fmt.Errorf("writing %s: %w", filepath.Base(path), err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("writing %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImageStore(t *testing.T) {
+	t.Run("missing file has no records", func(t *testing.T) {
+		store := NewImageStore(t.TempDir())
+
+		record, err := store.Load("web")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if record != nil {
+			t.Errorf("Expected no record, got %v", record)
+		}
+	})
+
+	t.Run("saves and loads records per stack", func(t *testing.T) {
+		dir := t.TempDir()
+		store := NewImageStore(dir)
+
+		updates := []ImageUpdate{{Service: "app", Image: "nginx:latest", Current: "sha256:old", Latest: "sha256:new"}}
+		if err := store.Save("web", newImageRecord(updates)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := store.Save("db", &ImageRecord{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		record, err := NewImageStore(dir).Load("web")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		want := ServiceImage{Service: "app", Image: "nginx:latest", ID: "sha256:old"}
+		if record == nil || len(record.Services) != 1 || record.Services[0] != want {
+			t.Errorf("Unexpected record: %+v", record)
+		}
+		if _, err := os.Stat(filepath.Join(dir, stateDir, "images.json")); err != nil {
+			t.Errorf("Expected state file: %v", err)
+		}
+	})
+
+	t.Run("corrupt file returns error", func(t *testing.T) {
+		dir := t.TempDir()
+		mustMkdir(t, filepath.Join(dir, stateDir))
+		writeFile(t, filepath.Join(dir, stateDir), "images.json", "{")
+
+		if _, err := NewImageStore(dir).Load("web"); err == nil {
+			t.Error("Expected error for corrupt state file")
+		}
+	})
+}
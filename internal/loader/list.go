@@ -0,0 +1,144 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func (m *StackManager) listStacks(stacks []*Stack) error {
+	WarnDuplicates(stacks)
+	m.populateLastActions(stacks)
+
+	switch m.format {
+	case OutputJSON, OutputYAML:
+		return encodeOutput(m.out, m.format, stacks)
+	case OutputWide:
+		writeStackTable(m.out, stacks, true)
+		return nil
+	case OutputTable, "":
+		writeStackTable(m.out, stacks, false)
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format: %s", m.format)
+	}
+}
+
+// populateLastActions sets the last recorded action of each stack.
+func (m *StackManager) populateLastActions(stacks []*Stack) {
+	last, err := m.state.Last()
+	if err != nil {
+		m.logger.Warning("Failed to load stack state: %v", err)
+		return
+	}
+
+	for _, stack := range stacks {
+		if record, ok := last[stack.Name]; ok {
+			stack.LastAction = &record
+		}
+	}
+}
+
+// encodeOutput writes v as JSON or YAML.
+func encodeOutput(out io.Writer, format OutputFormat, v any) error {
+	if format == OutputYAML {
+		encoder := yaml.NewEncoder(out)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(v); err != nil {
+			return fmt.Errorf("encoding output as YAML: %w", err)
+		}
+		return encoder.Close()
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("encoding output as JSON: %w", err)
+	}
+	return nil
+}
+
+func writeStackTable(out io.Writer, stacks []*Stack, wide bool) {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	if wide {
+		fmt.Fprintln(w, "ORDER\tSTACK\tSTATUS\tENABLED\tTAGS\tCONTAINERS\tDEPENDS ON\tLAST ACTION\tRESULT\tPATH")
+		fmt.Fprintln(w, "-----\t-----\t------\t-------\t----\t----------\t----------\t-----------\t------\t----")
+	} else {
+		fmt.Fprintln(w, "ORDER\tSTACK\tSTATUS\tENABLED\tTAGS\tLAST ACTION\tRESULT\tPATH")
+		fmt.Fprintln(w, "-----\t-----\t------\t-------\t----\t-----------\t------\t----")
+	}
+
+	now := time.Now()
+	for _, stack := range stacks {
+		lastAction, result := formatLastAction(stack.LastAction, now)
+		if wide {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", stack.Order, stack.Name, stack.Status,
+				formatEnabled(stack.Enabled), formatList(stack.Tags), formatContainerCounts(stack.Containers),
+				formatList(stack.DependsOn), lastAction, result, stack.Dir)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", stack.Order, stack.Name, stack.Status,
+				formatEnabled(stack.Enabled), formatList(stack.Tags), lastAction, result, stack.Dir)
+		}
+	}
+
+	//nolint:errcheck // Flush error is non-critical for display purposes
+	w.Flush()
+}
+
+// formatContainerCounts renders container counts sorted by state.
+// Format: {"running": 2, "exited": 1} -> "exited:1 running:2"
+func formatContainerCounts(counts map[string]int) string {
+	if len(counts) == 0 {
+		return "-"
+	}
+
+	parts := make([]string, 0, len(counts))
+	for _, state := range slices.Sorted(maps.Keys(counts)) {
+		parts = append(parts, fmt.Sprintf("%s:%d", state, counts[state]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatList(items []string) string {
+	if len(items) == 0 {
+		return "-"
+	}
+	return strings.Join(items, ",")
+}
+
+// formatLastAction renders the last action of a stack and its result.
+// Format: "start (2h ago)", "failed"
+func formatLastAction(record *ActionRecord, now time.Time) (action, result string) {
+	if record == nil {
+		return "-", "-"
+	}
+	return fmt.Sprintf("%s (%s ago)", record.Action, formatAge(now.Sub(record.Time))), string(record.Status)
+}
+
+// formatAge renders a duration in its largest whole unit, e.g. 45s, 3m, 2h or 5d.
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+func formatEnabled(enabled bool) string {
+	if enabled {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,99 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func newListTestManager(t *testing.T, format OutputFormat) (*StackManager, *bytes.Buffer) {
+	t.Helper()
+	dir := t.TempDir()
+	mustMkdir(t, filepath.Join(dir, "stacks", "10-web"))
+	mustMkdir(t, filepath.Join(dir, "stacks", "20-db"))
+
+	manager, out := newTestManager(t, dir, nil, &MockDockerExecutor{
+		RunQuietOut: []byte(`[{"Name":"web","Status":"running(2), exited(1)"}]`),
+	})
+	manager.SetOutputFormat(format)
+	return manager, out
+}
+
+func TestListStacks(t *testing.T) {
+	t.Run("json output", func(t *testing.T) {
+		manager, out := newListTestManager(t, OutputJSON)
+		if err := performAction(t, manager, "list"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var stacks []map[string]any
+		if err := json.Unmarshal(out.Bytes(), &stacks); err != nil {
+			t.Fatalf("Invalid JSON output: %v\n%s", err, out.String())
+		}
+		if len(stacks) != 2 {
+			t.Fatalf("Expected 2 stacks, got %d", len(stacks))
+		}
+		if stacks[0]["name"] != "web" || stacks[0]["order"] != "10" || stacks[0]["status"] != "degraded" {
+			t.Errorf("Unexpected first stack: %v", stacks[0])
+		}
+		containers, ok := stacks[0]["containers"].(map[string]any)
+		if !ok || containers["running"] != float64(2) || containers["exited"] != float64(1) {
+			t.Errorf("Unexpected container counts: %v", stacks[0]["containers"])
+		}
+	})
+
+	t.Run("json output without matching stacks", func(t *testing.T) {
+		for _, action := range []string{"list", "status"} {
+			manager, out := newListTestManager(t, OutputJSON)
+			stacks, err := manager.ResolveStacks(t.Context(), StackSelector{Tags: "missing"})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if err := manager.PerformAction(t.Context(), action, stacks); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if strings.TrimSpace(out.String()) != "[]" {
+				t.Errorf("Expected empty JSON list from %s, got %q", action, out.String())
+			}
+		}
+	})
+
+	t.Run("yaml output", func(t *testing.T) {
+		manager, out := newListTestManager(t, OutputYAML)
+		if err := performAction(t, manager, "list"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var stacks []map[string]any
+		if err := yaml.Unmarshal(out.Bytes(), &stacks); err != nil {
+			t.Fatalf("Invalid YAML output: %v\n%s", err, out.String())
+		}
+		if len(stacks) != 2 || stacks[1]["name"] != "db" || stacks[1]["status"] != "down" {
+			t.Errorf("Unexpected stacks: %v", stacks)
+		}
+	})
+
+	t.Run("wide output includes container counts", func(t *testing.T) {
+		manager, out := newListTestManager(t, OutputWide)
+		if err := performAction(t, manager, "list"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.Contains(out.String(), "CONTAINERS") || !strings.Contains(out.String(), "exited:1 running:2") {
+			t.Errorf("Unexpected wide output:\n%s", out.String())
+		}
+	})
+
+	t.Run("table output", func(t *testing.T) {
+		manager, out := newListTestManager(t, OutputTable)
+		if err := performAction(t, manager, "list"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.HasPrefix(out.String(), "ORDER") || strings.Contains(out.String(), "CONTAINERS") {
+			t.Errorf("Unexpected table output:\n%s", out.String())
+		}
+	})
+}
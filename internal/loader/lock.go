@@ -0,0 +1,254 @@
+package loader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// lockPollInterval is the delay between attempts to acquire a busy lock.
+const lockPollInterval = 200 * time.Millisecond
+
+// globalLockName is the lock taken exclusively by runs on all stacks and
+// shared by runs on selected stacks.
+const globalLockName = "global"
+
+// LockInfo describes the process holding a lock.
+type LockInfo struct {
+	Started time.Time `json:"started"`
+	Action  string    `json:"action"`
+	PID     int       `json:"pid"`
+}
+
+// String formats the holder for messages.
+func (i LockInfo) String() string {
+	return fmt.Sprintf("PID %d (%s, started %s)", i.PID, i.Action, i.Started.Local().Format(time.DateTime))
+}
+
+// LockOptions controls what happens when a lock is held by another process.
+type LockOptions struct {
+	// Timeout is how long to wait for the lock; zero waits forever if Wait is set
+	Timeout time.Duration
+	// Wait waits for the lock instead of failing immediately
+	Wait bool
+}
+
+// LockedError is returned when a lock is held by another composectl process.
+type LockedError struct {
+	// Target names what is locked, e.g. "stack web" or "all stacks"
+	Target  string
+	Holders []LockInfo
+	// Timeout is set if the lock could not be acquired within this time
+	Timeout time.Duration
+}
+
+func (e *LockedError) Error() string {
+	var msg strings.Builder
+	if e.Timeout > 0 {
+		fmt.Fprintf(&msg, "timed out after %s waiting for lock on %s", e.Timeout, e.Target)
+	} else {
+		fmt.Fprintf(&msg, "%s is locked by another composectl process", e.Target)
+	}
+
+	holders := make([]string, 0, len(e.Holders))
+	for _, holder := range e.Holders {
+		holders = append(holders, holder.String())
+	}
+	if len(holders) > 0 {
+		fmt.Fprintf(&msg, ": held by %s", strings.Join(holders, ", "))
+	}
+	return msg.String()
+}
+
+// RunLock is a set of advisory locks held for the duration of a run.
+type RunLock struct {
+	files []*os.File
+}
+
+// Release releases all locks.
+func (l *RunLock) Release() {
+	for _, f := range slices.Backward(l.files) {
+		//nolint:errcheck // Closing the file releases the lock
+		f.Close()
+	}
+	l.files = nil
+}
+
+// AcquireRunLock locks stacks in the base directory against other composectl
+// processes. Without stacks, all stacks are locked. Runs on different stacks
+// may proceed concurrently, but never alongside a run on all stacks. Waiting
+// for a lock stops with the error of ctx once it is done.
+func AcquireRunLock(
+	ctx context.Context, baseDir, action string, stacks []string, opts LockOptions, logger *Logger,
+) (*RunLock, error) {
+	dir := filepath.Join(baseDir, stateDir, "locks")
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:gosec // Lock directory is not secret
+		return nil, fmt.Errorf("creating lock directory: %w", err)
+	}
+
+	locker := &runLocker{
+		dir:    dir,
+		info:   LockInfo{Started: time.Now(), Action: action, PID: os.Getpid()},
+		opts:   opts,
+		logger: logger,
+		lock:   &RunLock{},
+	}
+	if opts.Wait && opts.Timeout > 0 {
+		locker.deadline = time.Now().Add(opts.Timeout)
+	}
+
+	if len(stacks) == 0 {
+		if err := locker.acquire(ctx, globalLockName, syscall.LOCK_EX, "all stacks"); err != nil {
+			return nil, err
+		}
+		return locker.lock, nil
+	}
+
+	if err := locker.acquire(ctx, globalLockName, syscall.LOCK_SH, "all stacks"); err != nil {
+		return nil, err
+	}
+	// A consistent order prevents deadlocks between runs on overlapping stacks
+	for _, stack := range slices.Sorted(slices.Values(stacks)) {
+		if err := locker.acquire(ctx, "stack-"+stack, syscall.LOCK_EX, "stack "+stack); err != nil {
+			locker.lock.Release()
+			return nil, err
+		}
+	}
+	return locker.lock, nil
+}
+
+// runLocker acquires the locks of a RunLock.
+type runLocker struct {
+	deadline time.Time
+	logger   *Logger
+	lock     *RunLock
+	dir      string
+	info     LockInfo
+	opts     LockOptions
+}
+
+// acquire takes the named lock, waiting for it as configured until ctx is
+// done. Exclusive holders record their LockInfo in the lock file.
+func (l *runLocker) acquire(ctx context.Context, name string, how int, target string) error {
+	path := filepath.Join(l.dir, name+".lock")
+	//nolint:gosec // Lock path is constructed from trusted base directory
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening lock file: %w", err)
+	}
+
+	var ticker *time.Ticker
+	for {
+		err := syscall.Flock(int(f.Fd()), how|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			f.Close() //nolint:errcheck,gosec // Lock was not acquired
+			return fmt.Errorf("locking %s: %w", target, err)
+		}
+
+		lockedErr := &LockedError{Target: target, Holders: l.holders(name)}
+		if !l.opts.Wait {
+			f.Close() //nolint:errcheck,gosec // Lock was not acquired
+			return lockedErr
+		}
+		if !l.deadline.IsZero() && time.Now().After(l.deadline) {
+			f.Close() //nolint:errcheck,gosec // Lock was not acquired
+			lockedErr.Timeout = l.opts.Timeout
+			return lockedErr
+		}
+		if ticker == nil {
+			l.logger.Console("Waiting for lock: %v", lockedErr)
+			ticker = time.NewTicker(lockPollInterval)
+			defer ticker.Stop()
+		}
+
+		select {
+		case <-ctx.Done():
+			f.Close() //nolint:errcheck,gosec // Lock was not acquired
+			return fmt.Errorf("waiting for lock on %s: %w", target, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+
+	if how == syscall.LOCK_EX {
+		data, err := json.Marshal(l.info)
+		if err == nil {
+			err = f.Truncate(0)
+		}
+		if err == nil {
+			_, err = f.WriteAt(data, 0)
+		}
+		if err != nil {
+			l.logger.Debug("Failed to record lock holder in %s: %v", path, err)
+		}
+	}
+
+	l.lock.files = append(l.lock.files, f)
+	return nil
+}
+
+// holders returns the processes holding the named lock. The global lock is
+// shared by runs on selected stacks, which record themselves in the locks of
+// their stacks instead.
+func (l *runLocker) holders(name string) []LockInfo {
+	if info, ok := probeLock(filepath.Join(l.dir, name+".lock")); ok {
+		return []LockInfo{info}
+	}
+	if name != globalLockName {
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(l.dir, "stack-*.lock"))
+	if err != nil {
+		return nil
+	}
+
+	var holders []LockInfo
+	for _, path := range paths {
+		if info, ok := probeLock(path); ok && !slices.Contains(holders, info) {
+			holders = append(holders, info)
+		}
+	}
+	return holders
+}
+
+// probeLock returns the holder of the lock file at path if it is held
+// exclusively.
+func probeLock(path string) (LockInfo, bool) {
+	f, err := os.Open(path) //nolint:gosec // Lock path is constructed from trusted base directory
+	if err != nil {
+		return LockInfo{}, false
+	}
+	defer f.Close() //nolint:errcheck // Read-only probe
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_SH|syscall.LOCK_NB); err == nil {
+		// Not held by anyone; closing the file releases the probe
+		return LockInfo{}, false
+	}
+	return readLockInfo(f)
+}
+
+// readLockInfo reads the holder recorded in a lock file. Only exclusive
+// holders record themselves, so the file may be empty or stale.
+func readLockInfo(f *os.File) (LockInfo, bool) {
+	data := make([]byte, 512)
+	n, err := f.ReadAt(data, 0)
+	if n == 0 && err != nil {
+		return LockInfo{}, false
+	}
+
+	var info LockInfo
+	if err := json.Unmarshal(data[:n], &info); err != nil || info.PID == 0 {
+		return LockInfo{}, false
+	}
+	return info, true
+}
@@ -0,0 +1,134 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcquireRunLock(t *testing.T) {
+	acquire := func(t *testing.T, dir, action string, stacks []string, opts LockOptions) (*RunLock, error) {
+		t.Helper()
+		lock, err := AcquireRunLock(t.Context(), dir, action, stacks, opts, newTestLogger(t))
+		if err == nil {
+			t.Cleanup(lock.Release)
+		}
+		return lock, err
+	}
+
+	assertLocked := func(t *testing.T, err error, target, action string) *LockedError {
+		t.Helper()
+		var lockedErr *LockedError
+		if !errors.As(err, &lockedErr) {
+			t.Fatalf("Expected LockedError, got: %v", err)
+		}
+		if lockedErr.Target != target {
+			t.Errorf("Expected target %q, got %q", target, lockedErr.Target)
+		}
+		if len(lockedErr.Holders) != 1 || lockedErr.Holders[0].PID != os.Getpid() ||
+			lockedErr.Holders[0].Action != action {
+			t.Errorf("Unexpected holders: %v", lockedErr.Holders)
+		}
+		return lockedErr
+	}
+
+	t.Run("run on all stacks excludes other runs", func(t *testing.T) {
+		dir := t.TempDir()
+		if _, err := acquire(t, dir, "start", nil, LockOptions{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		_, err := acquire(t, dir, "stop", nil, LockOptions{})
+		assertLocked(t, err, "all stacks", "start")
+
+		_, err = acquire(t, dir, "stop", []string{"web"}, LockOptions{})
+		assertLocked(t, err, "all stacks", "start")
+	})
+
+	t.Run("runs on different stacks proceed concurrently", func(t *testing.T) {
+		dir := t.TempDir()
+		if _, err := acquire(t, dir, "start", []string{"web", "db"}, LockOptions{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := acquire(t, dir, "stop", []string{"proxy"}, LockOptions{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		_, err := acquire(t, dir, "down", []string{"proxy", "db"}, LockOptions{})
+		lockedErr := assertLocked(t, err, "stack db", "start")
+		if !strings.Contains(lockedErr.Error(), "stack db is locked") || !strings.Contains(lockedErr.Error(), "(start,") {
+			t.Errorf("Unexpected message: %s", lockedErr.Error())
+		}
+	})
+
+	t.Run("run on all stacks names holders of selected stacks", func(t *testing.T) {
+		dir := t.TempDir()
+		if _, err := acquire(t, dir, "restart", []string{"web"}, LockOptions{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		_, err := acquire(t, dir, "start", nil, LockOptions{})
+		assertLocked(t, err, "all stacks", "restart")
+	})
+
+	t.Run("released lock can be acquired again", func(t *testing.T) {
+		dir := t.TempDir()
+		lock, err := AcquireRunLock(t.Context(), dir, "start", nil, LockOptions{}, newTestLogger(t))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		lock.Release()
+
+		if _, err := acquire(t, dir, "stop", nil, LockOptions{}); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("waits until lock is released", func(t *testing.T) {
+		dir := t.TempDir()
+		lock, err := AcquireRunLock(t.Context(), dir, "start", []string{"web"}, LockOptions{}, newTestLogger(t))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		time.AfterFunc(50*time.Millisecond, lock.Release)
+
+		if _, err := acquire(t, dir, "stop", []string{"web"}, LockOptions{Wait: true, Timeout: 5 * time.Second}); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("stops waiting when the context is done", func(t *testing.T) {
+		dir := t.TempDir()
+		if _, err := acquire(t, dir, "start", nil, LockOptions{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(t.Context())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+		_, err := AcquireRunLock(ctx, dir, "stop", nil, LockOptions{Wait: true}, newTestLogger(t))
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Waited %s after the context was cancelled", elapsed)
+		}
+	})
+
+	t.Run("gives up after timeout", func(t *testing.T) {
+		dir := t.TempDir()
+		if _, err := acquire(t, dir, "start", nil, LockOptions{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		_, err := acquire(t, dir, "stop", nil, LockOptions{Wait: true, Timeout: 10 * time.Millisecond})
+		lockedErr := assertLocked(t, err, "all stacks", "start")
+		if lockedErr.Timeout == 0 || !strings.Contains(lockedErr.Error(), "timed out") {
+			t.Errorf("Expected timeout error, got: %v", lockedErr)
+		}
+	})
+}
@@ -1,22 +1,55 @@
 package loader
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sync"
+	"syscall"
 	"time"
 )
 
+// levelOutput is the level of command output copied into the log file.
+const levelOutput = slog.LevelInfo + 2
+
+// Log entry attribute keys, used as field names by the JSON format.
+const (
+	logKeyAction   = "action"
+	logKeyStack    = "stack"
+	logKeyCommand  = "command"
+	logKeyDuration = "duration"
+	logKeyError    = "error"
+)
+
 // Logger handles logging to file and optionally to console.
 type Logger struct {
-	file    *os.File
-	logger  *log.Logger
-	verbose bool
+	file *logFile
+	// handler writes entries to the log file, and also to stdout in
+	// verbose mode
+	handler slog.Handler
+	// fileHandler writes entries to the log file only
+	fileHandler slog.Handler
+	verbose     bool
+}
+
+// logFile is the log file shared by a logger and the loggers derived from it.
+type logFile struct {
+	// mu serializes writes and rotation within the process
+	mu   sync.Mutex
+	path string
+	file *os.File
+	// lock is a lock file next to the log, shared while writing and held
+	// exclusively while rotating. It is nil while rotation is disabled.
+	lock     *os.File
+	rotation LogRotation
+	format   LogFormat
 }
 
-// NewLogger creates a new logger instance.
+// NewLogger creates a new logger instance writing the text format.
 func NewLogger(logPath string, verbose bool) (*Logger, error) {
 	// Create log directory if it doesn't exist
 	logDir := filepath.Dir(logPath)
@@ -24,57 +57,112 @@ func NewLogger(logPath string, verbose bool) (*Logger, error) {
 		return nil, fmt.Errorf("creating log directory: %w", err)
 	}
 
-	// Open log file in append mode
+	file, err := openLogFile(logPath)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := &Logger{
+		file:    &logFile{path: logPath, file: file, format: LogFormatText},
+		verbose: verbose,
+	}
+	logger.setHandlers(LogFormatText)
+	return logger, nil
+}
+
+// openLogFile opens the log file in append mode, creating it if needed.
+func openLogFile(path string) (*os.File, error) {
 	//nolint:gosec // Log path is from trusted base directory
-	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
 	if err != nil {
 		return nil, fmt.Errorf("opening log file: %w", err)
 	}
+	return file, nil
+}
+
+// SetFormat sets the format of log entries. It must be called before
+// loggers are derived with With.
+func (l *Logger) SetFormat(format LogFormat) error {
+	if format == "" {
+		format = LogFormatText
+	}
+	if !format.IsValid() {
+		return fmt.Errorf("invalid log format %q (expected text or json)", format)
+	}
 
-	// Create multi-writer for file and optionally stdout
-	var writers []io.Writer
-	writers = append(writers, file)
+	l.file.mu.Lock()
+	l.file.format = format
+	l.file.mu.Unlock()
+	l.setHandlers(format)
+	return nil
+}
 
-	// Only write to console in verbose mode
-	if verbose {
-		writers = append(writers, os.Stdout)
+// setHandlers creates the handlers writing entries in format.
+func (l *Logger) setHandlers(format LogFormat) {
+	level := slog.LevelInfo
+	if l.verbose {
+		level = slog.LevelDebug
 	}
 
-	multiWriter := io.MultiWriter(writers...)
-	logger := log.New(multiWriter, "", 0)
+	var out io.Writer = l.file
+	if l.verbose {
+		out = io.MultiWriter(l.file, os.Stdout)
+	}
 
+	l.handler = newLogHandler(format, out, level)
+	l.fileHandler = newLogHandler(format, l.file, level)
+}
+
+// With returns a logger adding the given attributes, as key-value pairs like
+// slog.Logger.With, to its entries. They appear as fields in the JSON format
+// only; text entries are unchanged.
+func (l *Logger) With(args ...any) *Logger {
+	attrs := slog.Group("", args...).Value.Group()
 	return &Logger{
-		file:    file,
-		logger:  logger,
-		verbose: verbose,
-	}, nil
+		file:        l.file,
+		handler:     l.handler.WithAttrs(attrs),
+		fileHandler: l.fileHandler.WithAttrs(attrs),
+		verbose:     l.verbose,
+	}
+}
+
+// WithAction returns a logger tagging its entries with the action being run.
+func (l *Logger) WithAction(action string) *Logger {
+	return l.With(logKeyAction, action)
 }
 
 // Close closes the log file.
 func (l *Logger) Close() error {
-	return l.file.Close()
+	f := l.file
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.lock != nil {
+		//nolint:errcheck // Closing the lock file only releases it
+		f.lock.Close()
+		f.lock = nil
+	}
+	return f.file.Close()
 }
 
 // Info logs an informational message.
 func (l *Logger) Info(format string, args ...any) {
-	l.log("INFO", format, args...)
+	l.log(l.handler, slog.LevelInfo, fmt.Sprintf(format, args...))
 }
 
 // Warning logs a warning message.
 func (l *Logger) Warning(format string, args ...any) {
-	l.log("WARN", format, args...)
+	l.log(l.handler, slog.LevelWarn, fmt.Sprintf(format, args...))
 }
 
 // Error logs an error message.
 func (l *Logger) Error(format string, args ...any) {
-	l.log("ERROR", format, args...)
+	l.log(l.handler, slog.LevelError, fmt.Sprintf(format, args...))
 }
 
 // Debug logs a debug message (only in verbose mode).
 func (l *Logger) Debug(format string, args ...any) {
-	if l.verbose {
-		l.log("DEBUG", format, args...)
-	}
+	l.log(l.handler, slog.LevelDebug, fmt.Sprintf(format, args...))
 }
 
 // Console prints a clean message to stdout and logs it to file with timestamp.
@@ -84,18 +172,192 @@ func (l *Logger) Console(format string, args ...any) {
 	// Print to stdout without timestamp
 	fmt.Println(message)
 	// Log to file with timestamp
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	fmt.Fprintf(l.file, "[%s] INFO: %s\n", timestamp, message)
+	l.log(l.fileHandler, slog.LevelInfo, message)
 }
 
-// GetWriter returns an io.Writer for command output.
-// Docker compose output always goes to both file and stdout.
-func (l *Logger) GetWriter() io.Writer {
-	return io.MultiWriter(l.file, os.Stdout)
+// OutputWriter returns a writer that records the output of a command of a
+// stack in the log file only, one timestamped entry per line. Text entries
+// are prefixed with the stack and command, e.g. "web/up"; stack is empty
+// for commands not run on a stack. Close logs a final line that does not
+// end with a newline.
+func (l *Logger) OutputWriter(stack, command string) io.WriteCloser {
+	return &outputWriter{logger: l, stack: stack, command: command}
 }
 
-func (l *Logger) log(level, format string, args ...any) {
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	message := fmt.Sprintf(format, args...)
-	l.logger.Printf("[%s] %s: %s", timestamp, level, message)
+// outputWriter logs complete lines of command output.
+type outputWriter struct {
+	logger  *Logger
+	stack   string
+	command string
+	buf     []byte
+}
+
+// Write buffers p and logs every complete line it contains.
+func (w *outputWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+
+	for {
+		idx := bytes.IndexByte(w.buf, '\n')
+		if idx == -1 {
+			break
+		}
+		w.logLine(w.buf[:idx])
+		w.buf = w.buf[idx+1:]
+	}
+
+	return len(p), nil
+}
+
+// Close logs any buffered partial line.
+func (w *outputWriter) Close() error {
+	if len(w.buf) > 0 {
+		w.logLine(w.buf)
+		w.buf = nil
+	}
+	return nil
+}
+
+func (w *outputWriter) logLine(line []byte) {
+	line = bytes.TrimRight(line, "\r")
+	if len(bytes.TrimSpace(line)) == 0 {
+		return
+	}
+
+	logger := w.logger
+	if w.stack != "" {
+		logger = logger.With(logKeyStack, w.stack)
+	}
+	logger = logger.With(logKeyCommand, w.command)
+	logger.log(logger.fileHandler, levelOutput, string(line))
+}
+
+func (l *Logger) log(handler slog.Handler, level slog.Level, message string) {
+	ctx := context.Background()
+	if !handler.Enabled(ctx, level) {
+		return
+	}
+	//nolint:errcheck // There is nowhere to report failed log writes
+	handler.Handle(ctx, slog.NewRecord(time.Now(), level, message, 0))
+}
+
+// newLogHandler returns the handler writing entries in format to w.
+func newLogHandler(format LogFormat, w io.Writer, level slog.Level) slog.Handler {
+	if format == LogFormatJSON {
+		return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level, ReplaceAttr: replaceJSONAttr})
+	}
+	return &textHandler{w: w, level: level}
+}
+
+// replaceJSONAttr names the fields of JSON entries.
+func replaceJSONAttr(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return attr
+	}
+
+	switch attr.Key {
+	case slog.TimeKey:
+		attr.Key = "timestamp"
+	case slog.MessageKey:
+		attr.Key = "message"
+	case slog.LevelKey:
+		attr.Value = slog.StringValue(levelName(attr.Value.Any().(slog.Level)))
+	case logKeyDuration:
+		// Seconds are easier to aggregate than nanoseconds
+		if attr.Value.Kind() == slog.KindDuration {
+			attr.Value = slog.Float64Value(attr.Value.Duration().Seconds())
+		}
+	}
+	return attr
+}
+
+// levelName returns the name of a level in log entries.
+func levelName(level slog.Level) string {
+	switch level {
+	case slog.LevelDebug:
+		return "DEBUG"
+	case slog.LevelInfo:
+		return "INFO"
+	case slog.LevelWarn:
+		return "WARN"
+	case slog.LevelError:
+		return "ERROR"
+	case levelOutput:
+		return "OUTPUT"
+	default:
+		return level.String()
+	}
+}
+
+// textHandler writes entries as "[timestamp] LEVEL: message" lines.
+// Attributes are not written, except the stack and command of command
+// output, which prefix its message as "stack/command | ".
+type textHandler struct {
+	w       io.Writer
+	level   slog.Level
+	stack   string
+	command string
+}
+
+// Enabled reports whether entries at level are written.
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+// Handle writes an entry.
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	message := r.Message
+	if r.Level == levelOutput {
+		prefix := h.command
+		if h.stack != "" {
+			prefix = h.stack + "/" + prefix
+		}
+		message = prefix + " | " + message
+	}
+
+	timestamp := r.Time.Format("2006-01-02 15:04:05")
+	_, err := fmt.Fprintf(h.w, "[%s] %s: %s\n", timestamp, levelName(r.Level), message)
+	return err
+}
+
+// WithAttrs returns a handler that keeps the stack and command of attrs.
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handler := *h
+	for _, attr := range attrs {
+		switch attr.Key {
+		case logKeyStack:
+			handler.stack = attr.Value.String()
+		case logKeyCommand:
+			handler.command = attr.Value.String()
+		}
+	}
+	return &handler
+}
+
+// WithGroup returns the handler unchanged, as attributes are not written.
+func (h *textHandler) WithGroup(_ string) slog.Handler {
+	return h
+}
+
+// Write appends an entry to the log file, rotating it first if needed.
+// Each call must contain whole entries.
+func (f *logFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.lock != nil {
+		f.prepareWrite(len(p))
+		//nolint:errcheck // Unlocking fails only if the lock was not held
+		defer syscall.Flock(int(f.lock.Fd()), syscall.LOCK_UN)
+	}
+
+	return f.file.Write(p)
+}
+
+// errorEntry formats an error entry written by the log file itself.
+func (f *logFile) errorEntry(message string) []byte {
+	var buf bytes.Buffer
+	record := slog.NewRecord(time.Now(), slog.LevelError, message, 0)
+	//nolint:errcheck // Writes to a buffer do not fail
+	newLogHandler(f.format, &buf, slog.LevelError).Handle(context.Background(), record)
+	return buf.Bytes()
 }
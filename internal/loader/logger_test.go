@@ -1,10 +1,13 @@
 package loader
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLogger(t *testing.T) {
@@ -113,4 +116,125 @@ func TestLogger(t *testing.T) {
 			t.Error("Parent directories were not created")
 		}
 	})
+
+	t.Run("output writer logs prefixed lines", func(t *testing.T) {
+		logPath := filepath.Join(t.TempDir(), "test.log")
+		logger, err := NewLogger(logPath, false)
+		if err != nil {
+			t.Fatalf("NewLogger failed: %v", err)
+		}
+
+		w := logger.OutputWriter("web", "up")
+		//nolint:errcheck // Writes to the log file are best effort
+		w.Write([]byte("Container web-app-1  Creating\r\nContainer web-app-1  Cre"))
+		//nolint:errcheck // Writes to the log file are best effort
+		w.Write([]byte("ated\n\nContainer web-app-1  Starting"))
+		//nolint:errcheck // Test cleanup
+		w.Close()
+		//nolint:errcheck // Test cleanup
+		logger.Close()
+
+		content, err := os.ReadFile(logPath)
+		if err != nil {
+			t.Fatalf("Failed to read log: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		want := []string{
+			"OUTPUT: web/up | Container web-app-1  Creating",
+			"OUTPUT: web/up | Container web-app-1  Created",
+			"OUTPUT: web/up | Container web-app-1  Starting",
+		}
+		if len(lines) != len(want) {
+			t.Fatalf("Expected %d log lines, got:\n%s", len(want), content)
+		}
+		for i := range want {
+			if !strings.HasSuffix(lines[i], want[i]) {
+				t.Errorf("Line %d: got %q, want suffix %q", i, lines[i], want[i])
+			}
+		}
+	})
+
+	t.Run("text format ignores attributes", func(t *testing.T) {
+		logPath := filepath.Join(t.TempDir(), "test.log")
+		logger, err := NewLogger(logPath, false)
+		if err != nil {
+			t.Fatalf("NewLogger failed: %v", err)
+		}
+
+		logger.With("action", "start", "stack", "web").Info("Starting stack: web")
+		//nolint:errcheck // Test cleanup
+		logger.Close()
+
+		content, err := os.ReadFile(logPath)
+		if err != nil {
+			t.Fatalf("Failed to read log: %v", err)
+		}
+		line := strings.TrimSpace(string(content))
+		if !regexp.MustCompile(`^\[\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\] INFO: Starting stack: web$`).MatchString(line) {
+			t.Errorf("Unexpected text entry: %q", line)
+		}
+	})
+
+	t.Run("json format", func(t *testing.T) {
+		logPath := filepath.Join(t.TempDir(), "test.log")
+		logger, err := NewLogger(logPath, false)
+		if err != nil {
+			t.Fatalf("NewLogger failed: %v", err)
+		}
+		if err := logger.SetFormat(LogFormatJSON); err != nil {
+			t.Fatalf("SetFormat failed: %v", err)
+		}
+
+		logger = logger.WithAction("start")
+		logger.With("stack", "web", "duration", 1500*time.Millisecond, "error", "boom").Error("Stack web failed: boom")
+		logger.Debug("hidden")
+		w := logger.OutputWriter("web", "up")
+		//nolint:errcheck // Writes to the log file are best effort
+		w.Write([]byte("Container web-app-1  Started\n"))
+		//nolint:errcheck // Test cleanup
+		logger.Close()
+
+		content, err := os.ReadFile(logPath)
+		if err != nil {
+			t.Fatalf("Failed to read log: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("Expected 2 entries, got:\n%s", content)
+		}
+
+		var entry struct {
+			Timestamp time.Time `json:"timestamp"`
+			Level     string    `json:"level"`
+			Message   string    `json:"message"`
+			Action    string    `json:"action"`
+			Stack     string    `json:"stack"`
+			Command   string    `json:"command"`
+			Error     string    `json:"error"`
+			Duration  float64   `json:"duration"`
+		}
+		if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+			t.Fatalf("Invalid JSON entry %q: %v", lines[0], err)
+		}
+		if entry.Timestamp.IsZero() || entry.Level != "ERROR" || entry.Message != "Stack web failed: boom" ||
+			entry.Action != "start" || entry.Stack != "web" || entry.Duration != 1.5 || entry.Error != "boom" {
+			t.Errorf("Unexpected entry: %s", lines[0])
+		}
+
+		entry.Error = ""
+		if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+			t.Fatalf("Invalid JSON entry %q: %v", lines[1], err)
+		}
+		if entry.Level != "OUTPUT" || entry.Message != "Container web-app-1  Started" ||
+			entry.Stack != "web" || entry.Command != "up" || entry.Action != "start" {
+			t.Errorf("Unexpected output entry: %s", lines[1])
+		}
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		logger := newTestLogger(t)
+		if err := logger.SetFormat("xml"); err == nil {
+			t.Error("Expected error for invalid log format")
+		}
+	})
 }
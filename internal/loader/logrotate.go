@@ -0,0 +1,232 @@
+package loader
+
+import (
+	"cmp"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// archiveTimeFormat is the timestamp in the names of rotated logs, e.g.
+// docker-loader.log.20261017-040000.gz. It sorts chronologically. Further
+// logs rotated within the same second get a counter, e.g.
+// docker-loader.log.20261017-040000-1.gz.
+const archiveTimeFormat = "20060102-150405"
+
+// SetRotation enables rotation of the log file according to rotation.
+//
+// Every composectl process logging to the same file must use the same
+// policy. Processes coordinate through a lock file next to the log: entries
+// are written under a shared lock and the log is rotated under an exclusive
+// one, after which the other processes reopen it. The modification time of
+// the lock file records the last rotation.
+func (l *Logger) SetRotation(rotation LogRotation) error {
+	f := l.file
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.rotation = rotation
+	if !rotation.Enabled() || f.lock != nil {
+		return nil
+	}
+
+	//nolint:gosec // Lock file path is constructed from trusted base directory
+	lock, err := os.OpenFile(f.path+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening log lock file: %w", err)
+	}
+	f.lock = lock
+	return nil
+}
+
+// prepareWrite takes the shared lock of the log file and rotates it if an
+// entry of size n would exceed the rotation policy. The caller holds f.mu
+// and releases the lock after writing. Rotation errors are logged.
+func (f *logFile) prepareWrite(n int) {
+	fd := int(f.lock.Fd())
+	//nolint:errcheck // Without the lock, entries are still written
+	syscall.Flock(fd, syscall.LOCK_SH)
+	f.reopenIfRotated()
+	if !f.needsRotation(n) {
+		return
+	}
+
+	// Converting the lock releases it first, so another process may have
+	// rotated the log meanwhile
+	//nolint:errcheck // Without the lock, entries are still written
+	syscall.Flock(fd, syscall.LOCK_EX)
+	f.reopenIfRotated()
+	if !f.needsRotation(n) {
+		return
+	}
+	if err := f.rotate(); err != nil {
+		//nolint:errcheck // There is nowhere else to report the error
+		f.file.Write(f.errorEntry(fmt.Sprintf("Failed to rotate log file: %v", err)))
+	}
+}
+
+// reopenIfRotated reopens the log file if another process rotated it.
+func (f *logFile) reopenIfRotated() {
+	current, err := f.file.Stat()
+	if err != nil {
+		return
+	}
+	if info, err := os.Stat(f.path); err == nil && os.SameFile(current, info) {
+		return
+	}
+
+	file, err := openLogFile(f.path)
+	if err != nil {
+		return
+	}
+	//nolint:errcheck // The rotated file was only written to
+	f.file.Close()
+	f.file = file
+}
+
+// needsRotation reports whether the log must be rotated before writing an
+// entry of size n. An empty log is never rotated.
+func (f *logFile) needsRotation(n int) bool {
+	info, err := f.file.Stat()
+	if err != nil || info.Size() == 0 {
+		return false
+	}
+
+	if maxBytes := f.rotation.MaxBytes(); maxBytes > 0 && info.Size()+int64(n) > maxBytes {
+		return true
+	}
+	if maxAge := f.rotation.MaxAgeDuration(); maxAge > 0 {
+		if lock, err := f.lock.Stat(); err == nil && time.Since(lock.ModTime()) >= maxAge {
+			return true
+		}
+	}
+	return false
+}
+
+// rotate moves the log aside, starts a new one, compresses the rotated log
+// and removes rotated logs beyond the retained number. The caller holds the
+// exclusive lock.
+func (f *logFile) rotate() error {
+	now := time.Now()
+	rotated := archivePath(f.path, now)
+	if err := os.Rename(f.path, rotated); err != nil {
+		return err
+	}
+
+	file, err := openLogFile(f.path)
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck // The rotated file was only written to
+	f.file.Close()
+	f.file = file
+
+	if err := os.Chtimes(f.lock.Name(), now, now); err != nil {
+		return fmt.Errorf("recording rotation time: %w", err)
+	}
+	if err := compressFile(rotated); err != nil {
+		return fmt.Errorf("compressing %s: %w", filepath.Base(rotated), err)
+	}
+	return f.pruneArchives()
+}
+
+// archivePath returns the name the log at path is rotated to at now. The
+// name is not taken by an earlier rotation, compressed or not. The caller
+// holds the exclusive lock, so no other process picks the same name.
+func archivePath(path string, now time.Time) string {
+	base := path + "." + now.Format(archiveTimeFormat)
+	rotated := base
+	for n := 1; archiveExists(rotated); n++ {
+		rotated = base + "-" + strconv.Itoa(n)
+	}
+	return rotated
+}
+
+// archiveExists reports whether a rotated log exists under the name.
+func archiveExists(rotated string) bool {
+	for _, name := range []string{rotated, rotated + ".gz"} {
+		if _, err := os.Lstat(name); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// compareArchives orders rotated logs from oldest to newest.
+func compareArchives(a, b string) int {
+	stampA, countA := archiveSequence(a)
+	stampB, countB := archiveSequence(b)
+	return cmp.Or(strings.Compare(stampA, stampB), cmp.Compare(countA, countB))
+}
+
+// archiveSequence returns the timestamp and counter of a rotated log.
+// Rotated logs without a counter have counter 0.
+func archiveSequence(archive string) (string, int) {
+	name := strings.TrimSuffix(archive, ".gz")
+	suffix := name[strings.LastIndex(name, ".")+1:]
+	count, found := strings.CutPrefix(suffix[min(len(suffix), len(archiveTimeFormat)):], "-")
+	if !found {
+		return suffix, 0
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return suffix, 0
+	}
+	return suffix[:len(archiveTimeFormat)], n
+}
+
+// pruneArchives removes the oldest rotated logs beyond the retained number.
+func (f *logFile) pruneArchives() error {
+	archives, err := filepath.Glob(f.path + ".*-*")
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(archives, compareArchives)
+
+	for len(archives) > f.rotation.RetainedFiles() {
+		if err := os.Remove(archives[0]); err != nil {
+			return fmt.Errorf("removing rotated log: %w", err)
+		}
+		archives = archives[1:]
+	}
+	return nil
+}
+
+// compressFile replaces path with a gzip-compressed copy named path.gz.
+// An existing path.gz is never overwritten.
+func compressFile(path string) error {
+	src, err := os.Open(path) //nolint:gosec // Rotated log path is from trusted base directory
+	if err != nil {
+		return err
+	}
+	defer src.Close() //nolint:errcheck // File is only read
+
+	//nolint:gosec // Rotated log path is from trusted base directory
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		//nolint:errcheck // Copy error takes precedence
+		dst.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		//nolint:errcheck // Compression error takes precedence
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,204 @@
+package loader
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newRotatingLogger(t *testing.T, logPath string, rotation LogRotation) *Logger {
+	t.Helper()
+	logger, err := NewLogger(logPath, false)
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+	t.Cleanup(func() {
+		//nolint:errcheck // Test cleanup
+		logger.Close()
+	})
+	if err := logger.SetRotation(rotation); err != nil {
+		t.Fatalf("SetRotation failed: %v", err)
+	}
+	return logger
+}
+
+func archives(t *testing.T, logPath string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(logPath + ".*.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matches
+}
+
+func readGzip(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path) //nolint:gosec // Test file
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() //nolint:errcheck // Test cleanup
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestLoggerRotation(t *testing.T) {
+	// A little more than half of the 1 MB limit, so the second entry rotates
+	large := strings.Repeat("x", 600<<10)
+
+	t.Run("rotates by size", func(t *testing.T) {
+		logPath := filepath.Join(t.TempDir(), "test.log")
+		logger := newRotatingLogger(t, logPath, LogRotation{MaxSize: 1})
+
+		logger.Info("first %s", large)
+		logger.Info("second %s", large)
+
+		rotated := archives(t, logPath)
+		if len(rotated) != 1 {
+			t.Fatalf("Expected one rotated log, got %v", rotated)
+		}
+		if !strings.Contains(readGzip(t, rotated[0]), "first") {
+			t.Error("Rotated log missing first entry")
+		}
+		content, err := os.ReadFile(logPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(content), "first") || !strings.Contains(string(content), "second") {
+			t.Error("Expected only the second entry in the new log")
+		}
+	})
+
+	t.Run("rotates by age", func(t *testing.T) {
+		logPath := filepath.Join(t.TempDir(), "test.log")
+		logger := newRotatingLogger(t, logPath, LogRotation{MaxAge: 1})
+
+		logger.Info("old entry")
+		logger.Info("recent entry")
+		if rotated := archives(t, logPath); len(rotated) != 0 {
+			t.Fatalf("Expected no rotation within max-age, got %v", rotated)
+		}
+
+		lastRotation := time.Now().Add(-25 * time.Hour)
+		if err := os.Chtimes(logPath+".lock", lastRotation, lastRotation); err != nil {
+			t.Fatal(err)
+		}
+		logger.Info("new entry")
+
+		rotated := archives(t, logPath)
+		if len(rotated) != 1 {
+			t.Fatalf("Expected one rotated log, got %v", rotated)
+		}
+		if !strings.Contains(readGzip(t, rotated[0]), "recent entry") {
+			t.Error("Rotated log missing previous entries")
+		}
+	})
+
+	t.Run("keeps max-files rotated logs", func(t *testing.T) {
+		dir := t.TempDir()
+		logPath := filepath.Join(dir, "test.log")
+		for _, stamp := range []string{"20240101-000000", "20240102-000000", "20240103-000000"} {
+			writeFile(t, dir, "test.log."+stamp+".gz", "")
+		}
+		logger := newRotatingLogger(t, logPath, LogRotation{MaxSize: 1, MaxFiles: 2})
+
+		logger.Info("first %s", large)
+		logger.Info("second %s", large)
+
+		rotated := archives(t, logPath)
+		if len(rotated) != 2 {
+			t.Fatalf("Expected two rotated logs, got %v", rotated)
+		}
+		if filepath.Base(rotated[0]) != "test.log.20240103-000000.gz" {
+			t.Errorf("Expected the oldest rotated logs removed, got %v", rotated)
+		}
+		if _, err := os.Stat(logPath + ".lock"); err != nil {
+			t.Errorf("Lock file removed: %v", err)
+		}
+	})
+
+	t.Run("back-to-back rotations keep every rotated log", func(t *testing.T) {
+		logPath := filepath.Join(t.TempDir(), "test.log")
+		logger := newRotatingLogger(t, logPath, LogRotation{MaxSize: 1, MaxFiles: 5})
+
+		logger.Info("first %s", large)
+		logger.Info("second %s", large)
+		logger.Info("third %s", large)
+
+		rotated := archives(t, logPath)
+		if len(rotated) != 2 {
+			t.Fatalf("Expected two rotated logs, got %v", rotated)
+		}
+		slices.SortFunc(rotated, compareArchives)
+		for i, want := range []string{"first", "second"} {
+			if !strings.Contains(readGzip(t, rotated[i]), want) {
+				t.Errorf("Expected %s entry in %s", want, filepath.Base(rotated[i]))
+			}
+		}
+	})
+
+	t.Run("prunes logs rotated within the same second in order", func(t *testing.T) {
+		dir := t.TempDir()
+		logPath := filepath.Join(dir, "test.log")
+		for _, stamp := range []string{"20240103-000000-2", "20240103-000000", "20240103-000000-10"} {
+			writeFile(t, dir, "test.log."+stamp+".gz", "")
+		}
+		logger := newRotatingLogger(t, logPath, LogRotation{MaxSize: 1, MaxFiles: 2})
+
+		logger.Info("first %s", large)
+		logger.Info("second %s", large)
+
+		rotated := archives(t, logPath)
+		if len(rotated) != 2 || !slices.Contains(rotated, logPath+".20240103-000000-10.gz") {
+			t.Errorf("Expected the newest rotated logs kept, got %v", rotated)
+		}
+	})
+
+	t.Run("other loggers follow rotation", func(t *testing.T) {
+		logPath := filepath.Join(t.TempDir(), "test.log")
+		rotation := LogRotation{MaxSize: 1}
+		first := newRotatingLogger(t, logPath, rotation)
+		second := newRotatingLogger(t, logPath, rotation)
+
+		first.Info("first %s", large)
+		first.Info("second %s", large)
+		second.Info("from second logger")
+
+		content, err := os.ReadFile(logPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(content), "from second logger") {
+			t.Error("Expected entry of the other logger in the new log")
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		logPath := filepath.Join(t.TempDir(), "test.log")
+		logger := newRotatingLogger(t, logPath, LogRotation{})
+
+		logger.Info("first %s", large)
+		logger.Info("second %s", large)
+		logger.Info("third %s", large)
+
+		if rotated := archives(t, logPath); len(rotated) != 0 {
+			t.Errorf("Expected no rotation, got %v", rotated)
+		}
+		if _, err := os.Stat(logPath + ".lock"); !os.IsNotExist(err) {
+			t.Error("Expected no lock file without rotation")
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// LogOptions controls which container logs the logs action shows.
+type LogOptions struct {
+	// Since shows logs since a timestamp or relative duration, e.g. 42m
+	Since string
+	// Tail limits the number of lines shown from the end of each log
+	Tail string
+	// Services restricts the logs to the given services
+	Services []string
+	// Follow keeps streaming new log output
+	Follow bool
+}
+
+// args returns the docker compose logs arguments for the options.
+func (o LogOptions) args() []string {
+	var args []string
+	if o.Follow {
+		args = append(args, "--follow")
+	}
+	if o.Since != "" {
+		args = append(args, "--since", o.Since)
+	}
+	if o.Tail != "" {
+		args = append(args, "--tail", o.Tail)
+	}
+	return append(args, o.Services...)
+}
+
+// showLogs shows the logs of stacks. The logs of several stacks are streamed
+// concurrently, with each line prefixed by the stack name.
+func (m *StackManager) showLogs(ctx context.Context, stacks []*Stack) error {
+	if len(stacks) == 1 {
+		return m.stackLogs(ctx, stacks[0], m.logOpts, nil)
+	}
+
+	opts, stacks, err := m.logOptionsPerStack(ctx, stacks)
+	if err != nil {
+		return err
+	}
+
+	width := 0
+	for _, stack := range stacks {
+		width = max(width, len(stack.Name))
+	}
+	color := colorEnabled(m.out)
+
+	var (
+		wg       sync.WaitGroup
+		outputMu sync.Mutex
+		errs     = make([]error, len(stacks))
+	)
+
+	for i, stack := range stacks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			prefix := fmt.Sprintf("%-*s | ", width, stack.Name)
+			if color {
+				prefix = colorize(prefix, i)
+			}
+
+			out := newPrefixWriter(m.out, &outputMu, prefix)
+			if err := m.stackLogs(ctx, stack, opts[i], out); err != nil {
+				errs[i] = fmt.Errorf("logs of stack %s: %w", stack.Name, err)
+			}
+			if err := out.Flush(); err != nil {
+				m.logger.Debug("Failed to flush output of stack %s: %v", stack.Name, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// logOptionsPerStack restricts the services of the log options to those each
+// stack defines, as docker compose fails on unknown services. Stacks defining
+// none of the services are left out.
+func (m *StackManager) logOptionsPerStack(ctx context.Context, stacks []*Stack) ([]LogOptions, []*Stack, error) {
+	opts := make([]LogOptions, 0, len(stacks))
+	if len(m.logOpts.Services) == 0 {
+		for range stacks {
+			opts = append(opts, m.logOpts)
+		}
+		return opts, stacks, nil
+	}
+
+	selected := make([]*Stack, 0, len(stacks))
+	for _, stack := range stacks {
+		defined, err := m.compose.DefinedServices(ctx, stack, m.loadStackConfig(stack))
+		if err != nil {
+			return nil, nil, fmt.Errorf("listing services of stack %s: %w", stack.Name, err)
+		}
+
+		var services []string
+		for _, service := range m.logOpts.Services {
+			if slices.Contains(defined, service) {
+				services = append(services, service)
+			}
+		}
+		if len(services) == 0 {
+			m.logger.Debug("Skipping logs of stack %s: none of the services are defined", stack.Name)
+			continue
+		}
+
+		stackOpts := m.logOpts
+		stackOpts.Services = services
+		opts = append(opts, stackOpts)
+		selected = append(selected, stack)
+	}
+
+	if len(selected) == 0 {
+		return nil, nil, fmt.Errorf("no selected stack defines service %s", strings.Join(m.logOpts.Services, ", "))
+	}
+	return opts, selected, nil
+}
+
+func (m *StackManager) stackLogs(ctx context.Context, stack *Stack, opts LogOptions, out io.Writer) error {
+	m.logger.Debug("Showing logs of stack: %s", stack.Name)
+	return m.composeFor(stack, out).Logs(ctx, stack, m.loadStackConfig(stack), opts)
+}
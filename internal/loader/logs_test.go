@@ -0,0 +1,131 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// echoExecutor writes the compose project name of each command to its output,
+// followed by the arguments of the logs command if there are any.
+type echoExecutor struct {
+	MockDockerExecutor
+	out io.Writer
+}
+
+func (e *echoExecutor) WithOutput(w io.Writer) DockerExecutor {
+	return &echoExecutor{out: w}
+}
+
+func (e *echoExecutor) Run(_ context.Context, args []string) error {
+	var project string
+	for i, arg := range args {
+		switch arg {
+		case "--project-name":
+			project = args[i+1]
+		case "logs":
+			if rest := args[i+1:]; len(rest) > 0 {
+				project += " " + strings.Join(rest, " ")
+			}
+		}
+	}
+	fmt.Fprintf(e.out, "web-1  | hello from %s\n", project)
+	return nil
+}
+
+func TestLogOptionsArgs(t *testing.T) {
+	opts := LogOptions{Follow: true, Since: "10m", Tail: "50", Services: []string{"web", "db"}}
+	assertSliceEqual(t, opts.args(), []string{"--follow", "--since", "10m", "--tail", "50", "web", "db"})
+
+	if args := (LogOptions{}).args(); len(args) != 0 {
+		t.Errorf("Expected no arguments, got %v", args)
+	}
+}
+
+func TestStackManagerLogs(t *testing.T) {
+	newManager := func(t *testing.T, executor DockerExecutor) (*StackManager, *bytes.Buffer) {
+		t.Helper()
+		dir := t.TempDir()
+		mustMkdir(t, filepath.Join(dir, "stacks", "10-proxy"))
+		mustMkdir(t, filepath.Join(dir, "stacks", "20-nextcloud"))
+
+		config := &Config{CommonArgs: []string{"--env-file", "/base/.env"}}
+		return newTestManager(t, dir, config, executor)
+	}
+
+	t.Run("single stack builds compose logs command", func(t *testing.T) {
+		mock := &MockDockerExecutor{}
+		manager, _ := newManager(t, mock)
+		manager.SetLogOptions(LogOptions{Tail: "20", Services: []string{"app"}})
+
+		if err := performAction(t, manager, "logs", "proxy"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(mock.RunCalls) != 1 {
+			t.Fatalf("Expected 1 call, got %d", len(mock.RunCalls))
+		}
+		stackDir := filepath.Join(manager.baseDir, "stacks", "10-proxy")
+		want := []string{"compose", "--env-file", "/base/.env", "--project-directory", stackDir,
+			"--project-name", "proxy", "logs", "--tail", "20", "app"}
+		assertSliceEqual(t, mock.RunCalls[0], want)
+	})
+
+	t.Run("multiple stacks are prefixed", func(t *testing.T) {
+		manager, out := newManager(t, &echoExecutor{})
+
+		if err := performAction(t, manager, "logs"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		output := out.String()
+		for _, want := range []string{
+			"proxy     | web-1  | hello from proxy\n",
+			"nextcloud | web-1  | hello from nextcloud\n",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("Expected %q in output:\n%s", want, output)
+			}
+		}
+		if strings.Contains(output, "\x1b[") {
+			t.Errorf("Expected no color codes when not writing to a terminal:\n%s", output)
+		}
+	})
+
+	t.Run("services are passed only to stacks defining them", func(t *testing.T) {
+		executor := &echoExecutor{}
+		executor.RunQuietFunc = func(args []string) ([]byte, error) {
+			if slices.Contains(args, "proxy") {
+				return []byte("traefik\n"), nil
+			}
+			return []byte("app\ndb\nredis\n"), nil
+		}
+		manager, out := newManager(t, executor)
+		manager.SetLogOptions(LogOptions{Services: []string{"app", "redis", "cron"}})
+
+		if err := performAction(t, manager, "logs"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		output := out.String()
+		if want := "nextcloud | web-1  | hello from nextcloud app redis\n"; output != want {
+			t.Errorf("Expected output %q, got %q", want, output)
+		}
+	})
+
+	t.Run("services defined by no stack fail", func(t *testing.T) {
+		executor := &echoExecutor{}
+		executor.RunQuietOut = []byte("app\n")
+		manager, _ := newManager(t, executor)
+		manager.SetLogOptions(LogOptions{Services: []string{"cron"}})
+
+		err := performAction(t, manager, "logs")
+		if err == nil || !strings.Contains(err.Error(), "no selected stack defines service cron") {
+			t.Errorf("Expected error about undefined service, got %v", err)
+		}
+	})
+}
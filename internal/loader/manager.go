@@ -1,20 +1,46 @@
 package loader
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
+	"slices"
 	"strings"
-	"text/tabwriter"
+	"sync"
+	"time"
 )
 
 // StackManager manages Docker Compose stacks.
 type StackManager struct {
 	repo    *StackRepository
 	compose *ComposeClient
+	hooks   *HookRunner
+	images  *ImageStore
+	state   *StateStore
 	logger  *Logger
+	config  *Config
+	out     io.Writer
+	baseDir string
+	// version is the composectl version recorded in the stack state
+	version string
+	format  OutputFormat
+	logOpts LogOptions
+	// composeArgs are the arguments of the compose passthrough action
+	composeArgs []string
+	results     []*StackResult
+	// interrupt is closed by Interrupt
+	interrupt     chan struct{}
+	interruptOnce sync.Once
+	// healthInterval is the delay between health polls of wait-healthy stacks
+	healthInterval time.Duration
+	dryRun         bool
 }
 
+// stackFunc performs an operation on a single stack. When out is non-nil,
+// command output is written to it instead of the terminal.
+type stackFunc func(ctx context.Context, stack *Stack, out io.Writer) error
+
 // NewStackManager creates a new stack manager.
 func NewStackManager(baseDir string, config *Config, logger *Logger, dryRun bool) *StackManager {
 	executor := NewDockerExecutor(logger, dryRun)
@@ -22,146 +48,322 @@ func NewStackManager(baseDir string, config *Config, logger *Logger, dryRun bool
 	repo := NewStackRepository(baseDir, logger, compose)
 
 	return &StackManager{
-		repo:    repo,
-		compose: compose,
-		logger:  logger,
+		repo:           repo,
+		compose:        compose,
+		hooks:          NewHookRunner(logger, dryRun),
+		images:         NewImageStore(baseDir),
+		state:          NewStateStore(baseDir),
+		logger:         logger,
+		config:         config,
+		out:            os.Stdout,
+		baseDir:        baseDir,
+		format:         OutputTable,
+		interrupt:      make(chan struct{}),
+		healthInterval: defaultHealthInterval,
+		dryRun:         dryRun,
+	}
+}
+
+// SetOutputFormat sets the output format used by the list and status actions.
+func (m *StackManager) SetOutputFormat(format OutputFormat) {
+	m.format = format
+}
+
+// SetLogOptions sets the options used by the logs action.
+func (m *StackManager) SetLogOptions(opts LogOptions) {
+	m.logOpts = opts
+}
+
+// SetComposeArgs sets the docker compose arguments run by the compose action.
+func (m *StackManager) SetComposeArgs(args []string) {
+	m.composeArgs = args
+}
+
+// SetVersion sets the composectl version recorded with stack actions.
+func (m *StackManager) SetVersion(version string) {
+	m.version = version
+}
+
+// Interrupt makes the running action stop once the stacks in progress have
+// finished; the remaining stacks are skipped. Commands already running are
+// not affected; cancelling the context passed to the action kills them.
+// Interrupt may be called from any goroutine.
+func (m *StackManager) Interrupt() {
+	m.interruptOnce.Do(func() { close(m.interrupt) })
+}
+
+// interrupted reports whether Interrupt was called or ctx is done.
+func (m *StackManager) interrupted(ctx context.Context) bool {
+	select {
+	case <-m.interrupt:
+		return true
+	default:
+		return ctx.Err() != nil
 	}
 }
 
-// ExecuteAction executes the specified action on stacks.
-func (m *StackManager) ExecuteAction(action, targetStack string) error {
+// interruptContext returns a context that is also cancelled by Interrupt,
+// for waits that must end as soon as the run is interrupted.
+func (m *StackManager) interruptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-m.interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// Results returns the per-stack outcome of the last executed action.
+func (m *StackManager) Results() []*StackResult {
+	return m.results
+}
+
+// PerformAction executes the specified action on stacks returned by ResolveStacks.
+func (m *StackManager) PerformAction(ctx context.Context, action string, stacks []*Stack) error {
 	act := Action(action)
 	if !act.IsValid() {
 		return fmt.Errorf("unrecognized action: %s", action)
 	}
 
-	stacks, err := m.getStacks(targetStack)
-	if err != nil {
-		return err
-	}
-
 	if len(stacks) == 0 {
 		m.logger.Warning("No stacks found")
+		if (act == ActionList || act == ActionStatus) && (m.format == OutputJSON || m.format == OutputYAML) {
+			// Scripts parsing the output expect a list even if it is empty
+			return encodeOutput(m.out, m.format, []*Stack{})
+		}
 		return nil
 	}
 
-	return m.performAction(act, stacks)
+	return m.performAction(ctx, act, stacks)
 }
 
-func (m *StackManager) getStacks(targetStack string) ([]*Stack, error) {
-	if targetStack != "" {
-		stack, err := m.repo.FindByName(targetStack)
-		if err != nil {
-			return nil, err
-		}
-		return []*Stack{stack}, nil
+// ResolveStacks returns the stacks targeted by an action in start order.
+// An empty selector selects all stacks.
+func (m *StackManager) ResolveStacks(ctx context.Context, selector StackSelector) ([]*Stack, error) {
+	stacks, err := m.repo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("discovering stacks: %w", err)
 	}
 
-	stacks, err := m.repo.FindAll()
+	for _, unknown := range UnknownDependencies(stacks) {
+		m.logger.Warning("Ignoring dependency: %s", unknown)
+	}
+
+	sorted, err := SortByDependencies(stacks)
 	if err != nil {
-		return nil, fmt.Errorf("discovering stacks: %w", err)
+		return nil, fmt.Errorf("ordering stacks: %w", err)
 	}
 
-	return stacks, nil
+	if selector.IsEmpty() {
+		return sorted, nil
+	}
+
+	return m.repo.Select(sorted, selector)
 }
 
-func (m *StackManager) performAction(action Action, stacks []*Stack) error {
+func (m *StackManager) performAction(ctx context.Context, action Action, stacks []*Stack) error {
 	switch action {
 	case ActionList:
 		return m.listStacks(stacks)
+	case ActionStatus:
+		return m.showStatus(ctx, stacks)
+	case ActionLogs:
+		return m.showLogs(ctx, stacks)
+	case ActionHistory:
+		return m.showHistory(stacks)
+	case ActionCompose:
+		return m.runCompose(ctx, stacks)
+	case ActionEnable, ActionDisable:
+		return m.setEnabled(stacks, action == ActionEnable)
+	case ActionCheckUpdates:
+		return m.checkUpdates(ctx, m.withoutDisabled(stacks))
 	case ActionStart:
-		return m.executeWithDuplicateCheck(stacks, m.startStack)
+		return m.executeWithDuplicateCheck(ctx, action, m.withoutDisabled(stacks), m.startStack)
 	case ActionStop:
-		return m.executeWithDuplicateCheck(stacks, m.stopStack)
+		// Dependents are stopped before the stacks they depend on
+		return m.executeWithDuplicateCheck(ctx, action, reversed(stacks), m.stopStack)
 	case ActionDown:
-		return m.executeWithDuplicateCheck(stacks, m.downStack)
+		return m.executeWithDuplicateCheck(ctx, action, reversed(stacks), m.downStack)
 	case ActionRestart, ActionReload:
-		return m.executeWithDuplicateCheck(stacks, m.restartStack)
+		return m.executeWithDuplicateCheck(ctx, action, m.withoutDisabled(stacks), m.restartStack)
+	case ActionUpdate:
+		return m.executeWithDuplicateCheck(ctx, action, m.withoutDisabled(stacks), m.updateStack)
+	case ActionRollback:
+		return m.executeWithDuplicateCheck(ctx, action, stacks, m.rollbackStack)
 	default:
 		return fmt.Errorf("unrecognized action: %s", action)
 	}
 }
 
-func (m *StackManager) executeWithDuplicateCheck(stacks []*Stack, fn func(*Stack) error) error {
-	if err := CheckDuplicates(stacks); err != nil {
-		return err
+// withoutDisabled drops disabled stacks unless they were targeted by name.
+func (m *StackManager) withoutDisabled(stacks []*Stack) []*Stack {
+	enabled := make([]*Stack, 0, len(stacks))
+	for _, stack := range stacks {
+		if !stack.Enabled && !stack.explicit {
+			m.logger.Info("Skipping disabled stack: %s", stack.Name)
+			continue
+		}
+		enabled = append(enabled, stack)
+	}
+	return enabled
+}
+
+// setEnabled enables or disables each stack.
+func (m *StackManager) setEnabled(stacks []*Stack, enabled bool) error {
+	action := ActionDisable
+	if enabled {
+		action = ActionEnable
 	}
 
 	for _, stack := range stacks {
-		if err := fn(stack); err != nil {
+		if m.dryRun {
+			m.logger.Info("[DRY-RUN] Would %s stack: %s", action, stack.Name)
+			continue
+		}
+		if err := m.repo.SetEnabled(stack, enabled); err != nil {
 			return err
 		}
+		m.logger.Console("==> Stack %s: %sd", stack.Name, action)
+		m.logger.Info("Stack %s: %sd", stack.Name, action)
 	}
-
 	return nil
 }
 
-func (m *StackManager) listStacks(stacks []*Stack) error {
-	WarnDuplicates(stacks)
-
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	fmt.Fprintln(w, "ORDER\tSTACK\tSTATUS\tPATH")
-	fmt.Fprintln(w, "-----\t-----\t------\t----")
-
-	for _, stack := range stacks {
-		dirName := filepath.Base(stack.Dir)
-		order := strings.SplitN(dirName, "-", 2)[0]
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", order, stack.Name, stack.Status, stack.Dir)
+// composeFor returns the compose client for operations on stack, writing
+// command output to out.
+func (m *StackManager) composeFor(stack *Stack, out io.Writer) *ComposeClient {
+	compose := m.compose.WithLogger(m.stackLogger(stack))
+	if out == nil {
+		return compose
 	}
-
-	//nolint:errcheck // Flush error is non-critical for display purposes
-	w.Flush()
-	return nil
+	return compose.WithOutput(out)
 }
 
-func (m *StackManager) startStack(stack *Stack) error {
-	m.logger.Console("==> Starting stack: %s", stack.Name)
-	m.logger.Info("Starting stack: %s", stack.Name)
+// stackLogger returns the logger for entries about stack, which carry the
+// stack name in the JSON format.
+func (m *StackManager) stackLogger(stack *Stack) *Logger {
+	return m.logger.With(logKeyStack, stack.Name)
+}
 
+// loadStackConfig loads the stack configuration, falling back to an empty
+// configuration if it cannot be read or is invalid. Every action uses the
+// fallback, so that a broken config.yaml never prevents stopping a stack; its
+// hooks are skipped then, which is logged as an error.
+func (m *StackManager) loadStackConfig(stack *Stack) *StackConfig {
 	stackConfig, err := LoadStackConfig(stack.Dir)
 	if err != nil {
-		m.logger.Warning("Failed to load stack config: %v", err)
-		stackConfig = &StackConfig{}
+		m.stackLogger(stack).Error("Failed to load config of stack %s, using defaults without hooks: %v",
+			stack.Name, err)
+		return &StackConfig{}
 	}
+	return stackConfig
+}
 
-	if m.compose.HasContainers(stack) {
-		m.logger.Debug("Containers exist for stack %s, using 'start'", stack.Name)
-		return m.compose.Start(stack, stackConfig)
-	}
+// withHooks runs fn between the pre and post hooks of a stack operation.
+// Post hooks only run if fn succeeds.
+func (m *StackManager) withHooks(
+	ctx context.Context, stack *Stack, action Action, pre, post []Hook, out io.Writer, fn func() error,
+) error {
+	env := stackHookEnv(m.baseDir, stack, action)
+	phase := hookPhase(action)
+	hooks := m.hooks.WithLogger(m.stackLogger(stack))
 
-	m.logger.Debug("No containers found for stack %s, using 'up'", stack.Name)
-	return m.compose.Up(stack, stackConfig)
+	if err := hooks.Run(ctx, "pre-"+phase, pre, stack.Dir, env, out); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	return hooks.Run(ctx, "post-"+phase, post, stack.Dir, env, out)
 }
 
-func (m *StackManager) stopStack(stack *Stack) error {
-	m.logger.Console("==> Stopping stack: %s", stack.Name)
-	m.logger.Info("Stopping stack: %s", stack.Name)
+// hookPhase returns the hook name suffix for an action; down shares stop hooks.
+func hookPhase(action Action) string {
+	if action == ActionDown {
+		return string(ActionStop)
+	}
+	return string(action)
+}
 
-	stackConfig, err := LoadStackConfig(stack.Dir)
+// runCompose passes the compose arguments through to docker compose for a
+// single stack, using the same arguments as every other action.
+func (m *StackManager) runCompose(ctx context.Context, stacks []*Stack) error {
+	stack, err := singleStack(ActionCompose, stacks)
 	if err != nil {
-		m.logger.Warning("Failed to load stack config: %v", err)
-		stackConfig = &StackConfig{}
+		return err
 	}
 
-	return m.compose.Stop(stack, stackConfig)
+	m.logger.Info("Running compose command for stack %s: %s", stack.Name, strings.Join(m.composeArgs, " "))
+	return m.compose.Compose(ctx, stack, m.loadStackConfig(stack), m.composeArgs)
 }
 
-func (m *StackManager) downStack(stack *Stack) error {
-	m.logger.Console("==> Taking down stack: %s", stack.Name)
-	m.logger.Info("Taking down stack: %s", stack.Name)
+func reversed(stacks []*Stack) []*Stack {
+	result := slices.Clone(stacks)
+	slices.Reverse(result)
+	return result
+}
 
-	stackConfig, err := LoadStackConfig(stack.Dir)
-	if err != nil {
-		m.logger.Warning("Failed to load stack config: %v", err)
-		stackConfig = &StackConfig{}
-	}
+func (m *StackManager) startStack(ctx context.Context, stack *Stack, out io.Writer) error {
+	logger := m.stackLogger(stack)
+	logger.Console("==> Starting stack: %s", stack.Name)
+	logger.Info("Starting stack: %s", stack.Name)
+
+	stackConfig := m.loadStackConfig(stack)
+	hooks := stackConfig.Hooks
+
+	return m.withHooks(ctx, stack, ActionStart, hooks.PreStart, hooks.PostStart, out, func() error {
+		var err error
+		compose := m.composeFor(stack, out)
+		if compose.HasContainers(ctx, stack) {
+			logger.Debug("Containers exist for stack %s, using 'start'", stack.Name)
+			err = compose.Start(ctx, stack, stackConfig)
+		} else {
+			logger.Debug("No containers found for stack %s, using 'up'", stack.Name)
+			err = compose.Up(ctx, stack, stackConfig)
+		}
+		if err != nil {
+			return err
+		}
+
+		if stackConfig.WaitHealthy && !m.dryRun {
+			return m.waitHealthy(ctx, stack, compose, stackConfig.HealthTimeoutDuration())
+		}
+		return nil
+	})
+}
+
+func (m *StackManager) stopStack(ctx context.Context, stack *Stack, out io.Writer) error {
+	logger := m.stackLogger(stack)
+	logger.Console("==> Stopping stack: %s", stack.Name)
+	logger.Info("Stopping stack: %s", stack.Name)
+
+	stackConfig := m.loadStackConfig(stack)
+	hooks := stackConfig.Hooks
+
+	return m.withHooks(ctx, stack, ActionStop, hooks.PreStop, hooks.PostStop, out, func() error {
+		return m.composeFor(stack, out).Stop(ctx, stack, stackConfig)
+	})
+}
+
+func (m *StackManager) downStack(ctx context.Context, stack *Stack, out io.Writer) error {
+	logger := m.stackLogger(stack)
+	logger.Console("==> Taking down stack: %s", stack.Name)
+	logger.Info("Taking down stack: %s", stack.Name)
+
+	stackConfig := m.loadStackConfig(stack)
+	hooks := stackConfig.Hooks
 
-	return m.compose.Down(stack, stackConfig)
+	return m.withHooks(ctx, stack, ActionDown, hooks.PreStop, hooks.PostStop, out, func() error {
+		return m.composeFor(stack, out).Down(ctx, stack, stackConfig)
+	})
 }
 
-func (m *StackManager) restartStack(stack *Stack) error {
-	if err := m.stopStack(stack); err != nil {
+func (m *StackManager) restartStack(ctx context.Context, stack *Stack, out io.Writer) error {
+	if err := m.stopStack(ctx, stack, out); err != nil {
 		return err
 	}
-	return m.startStack(stack)
+	return m.startStack(ctx, stack, out)
 }
@@ -1,11 +1,62 @@
 package loader
 
 import (
+	"context"
+	"encoding/json"
+	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
+// composeCall is a compose command recorded by timingExecutor.
+type composeCall struct {
+	start, end time.Time
+}
+
+// timingExecutor records when the compose command of each project runs.
+// Every command takes delay, so that overlapping commands can be detected.
+type timingExecutor struct {
+	delay time.Duration
+
+	mu    sync.Mutex
+	calls map[string]composeCall
+}
+
+func (e *timingExecutor) Run(_ context.Context, args []string) error {
+	start := time.Now()
+	time.Sleep(e.delay)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.calls == nil {
+		e.calls = make(map[string]composeCall)
+	}
+	if i := slices.Index(args, "--project-name"); i >= 0 && i+1 < len(args) {
+		e.calls[args[i+1]] = composeCall{start: start, end: time.Now()}
+	}
+	return nil
+}
+
+func (e *timingExecutor) RunQuiet(context.Context, []string) ([]byte, error) {
+	return nil, nil
+}
+
+// call returns the recorded compose command of the project.
+func (e *timingExecutor) call(t *testing.T, project string) composeCall {
+	t.Helper()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	call, ok := e.calls[project]
+	if !ok {
+		t.Fatalf("Expected a compose command for %s, got %v", project, e.calls)
+	}
+	return call
+}
+
 func TestCheckDuplicates(t *testing.T) {
 	t.Run("no duplicates returns nil", func(t *testing.T) {
 		stacks := []*Stack{
@@ -44,13 +95,13 @@ func TestWarnDuplicates(_ *testing.T) {
 	WarnDuplicates([]*Stack{})
 }
 
-func TestStackManagerExecuteAction(t *testing.T) {
+func TestStackManagerPerformAction(t *testing.T) {
 	t.Run("invalid action returns error", func(t *testing.T) {
 		dir := t.TempDir()
 		config := &Config{}
 		manager := NewStackManager(dir, config, newTestLogger(t), true) // dry-run
 
-		err := manager.ExecuteAction("invalid", "")
+		err := manager.PerformAction(t.Context(), "invalid", []*Stack{{Name: "web", Dir: dir}})
 		if err == nil {
 			t.Error("Expected error for invalid action")
 		}
@@ -67,7 +118,7 @@ func TestStackManagerExecuteAction(t *testing.T) {
 		config := &Config{UpArgs: []string{"--detach"}}
 		manager := NewStackManager(dir, config, newTestLogger(t), true) // dry-run
 
-		err := manager.ExecuteAction("start", "web")
+		err := performAction(t, manager, "start", "web")
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
@@ -81,7 +132,7 @@ func TestStackManagerExecuteAction(t *testing.T) {
 		config := &Config{Timeout: 10}
 		manager := NewStackManager(dir, config, newTestLogger(t), true) // dry-run
 
-		err := manager.ExecuteAction("stop", "web")
+		err := performAction(t, manager, "stop", "web")
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
@@ -95,7 +146,7 @@ func TestStackManagerExecuteAction(t *testing.T) {
 		config := &Config{}
 		manager := NewStackManager(dir, config, newTestLogger(t), true)
 
-		err := manager.ExecuteAction("down", "web")
+		err := performAction(t, manager, "down", "web")
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
@@ -109,7 +160,7 @@ func TestStackManagerExecuteAction(t *testing.T) {
 		config := &Config{Timeout: 10}
 		manager := NewStackManager(dir, config, newTestLogger(t), true)
 
-		err := manager.ExecuteAction("restart", "web")
+		err := performAction(t, manager, "restart", "web")
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
@@ -123,12 +174,38 @@ func TestStackManagerExecuteAction(t *testing.T) {
 		config := &Config{}
 		manager := NewStackManager(dir, config, newTestLogger(t), false)
 
-		err := manager.ExecuteAction("list", "")
+		err := performAction(t, manager, "list")
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
 	})
 
+	t.Run("parallel start executes all waves", func(t *testing.T) {
+		dir := t.TempDir()
+		stacksDir := filepath.Join(dir, "stacks")
+		mustMkdir(t, filepath.Join(stacksDir, "01-proxy"))
+		mustMkdir(t, filepath.Join(stacksDir, "02-web"))
+		mustMkdir(t, filepath.Join(stacksDir, "02-api"))
+
+		executor := &timingExecutor{delay: 100 * time.Millisecond}
+		manager, _ := newTestManager(t, dir, &Config{Parallelism: 2}, executor)
+
+		if err := performAction(t, manager, "start"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		proxy, web, api := executor.call(t, "proxy"), executor.call(t, "web"), executor.call(t, "api")
+		for _, call := range []composeCall{web, api} {
+			if call.start.Before(proxy.end) {
+				t.Errorf("Expected the second wave to start after the first finished")
+			}
+		}
+		if !web.start.Before(api.end) || !api.start.Before(web.end) {
+			t.Errorf("Expected the stacks of the second wave to run concurrently: web %v-%v, api %v-%v",
+				web.start, web.end, api.start, api.end)
+		}
+	})
+
 	t.Run("unknown target stack returns error", func(t *testing.T) {
 		dir := t.TempDir()
 		stacksDir := filepath.Join(dir, "stacks")
@@ -137,7 +214,7 @@ func TestStackManagerExecuteAction(t *testing.T) {
 		config := &Config{}
 		manager := NewStackManager(dir, config, newTestLogger(t), true)
 
-		err := manager.ExecuteAction("start", "unknown")
+		err := performAction(t, manager, "start", "unknown")
 		if err == nil {
 			t.Error("Expected error for unknown stack")
 		}
@@ -152,7 +229,7 @@ func TestStackManagerExecuteAction(t *testing.T) {
 		config := &Config{}
 		manager := NewStackManager(dir, config, newTestLogger(t), true)
 
-		err := manager.ExecuteAction("start", "")
+		err := performAction(t, manager, "start")
 		if err == nil {
 			t.Error("Expected error for duplicate stacks")
 		}
@@ -161,6 +238,26 @@ func TestStackManagerExecuteAction(t *testing.T) {
 		}
 	})
 
+	t.Run("fails on dependency cycle", func(t *testing.T) {
+		dir := t.TempDir()
+		stacksDir := filepath.Join(dir, "stacks")
+		mustMkdir(t, filepath.Join(stacksDir, "01-web"))
+		mustMkdir(t, filepath.Join(stacksDir, "02-api"))
+		writeFile(t, filepath.Join(stacksDir, "01-web"), "config.yaml", `depends-on: ["api"]`)
+		writeFile(t, filepath.Join(stacksDir, "02-api"), "config.yaml", `depends-on: ["web"]`)
+
+		config := &Config{}
+		manager := NewStackManager(dir, config, newTestLogger(t), true)
+
+		err := performAction(t, manager, "start")
+		if err == nil {
+			t.Fatal("Expected error for dependency cycle")
+		}
+		if !strings.Contains(err.Error(), "cycle") {
+			t.Errorf("Expected 'cycle' in error, got: %s", err.Error())
+		}
+	})
+
 	t.Run("no stacks logs warning", func(t *testing.T) {
 		dir := t.TempDir()
 		mustMkdir(t, filepath.Join(dir, "stacks")) // Empty stacks dir
@@ -168,9 +265,193 @@ func TestStackManagerExecuteAction(t *testing.T) {
 		config := &Config{}
 		manager := NewStackManager(dir, config, newTestLogger(t), true)
 
-		err := manager.ExecuteAction("start", "")
+		err := performAction(t, manager, "start")
 		if err != nil {
 			t.Errorf("Expected nil for empty stacks, got: %v", err)
 		}
 	})
 }
+
+func TestStackManagerResolveStacks(t *testing.T) {
+	t.Run("orders selected stacks by dependencies", func(t *testing.T) {
+		dir := t.TempDir()
+		stacksDir := filepath.Join(dir, "stacks")
+		mustMkdir(t, filepath.Join(stacksDir, "10-app"))
+		mustMkdir(t, filepath.Join(stacksDir, "20-db"))
+		mustMkdir(t, filepath.Join(stacksDir, "30-proxy"))
+		writeFile(t, filepath.Join(stacksDir, "10-app"), "config.yaml", `depends-on: ["db"]`)
+
+		manager := NewStackManager(dir, &Config{}, newTestLogger(t), true)
+
+		stacks, err := manager.ResolveStacks(t.Context(), StackSelector{Include: []string{"app", "db"}})
+		if err != nil {
+			t.Fatalf("ResolveStacks failed: %v", err)
+		}
+		assertSliceEqual(t, stackNames(stacks), []string{"db", "app"})
+	})
+
+	t.Run("ignores unknown dependency", func(t *testing.T) {
+		dir := t.TempDir()
+		stacksDir := filepath.Join(dir, "stacks")
+		mustMkdir(t, filepath.Join(stacksDir, "10-web"))
+		mustMkdir(t, filepath.Join(stacksDir, "20-db"))
+		writeFile(t, filepath.Join(stacksDir, "10-web"), "config.yaml", `depends-on: ["nope"]`)
+
+		manager := NewStackManager(dir, &Config{}, newTestLogger(t), true)
+
+		stacks, err := manager.ResolveStacks(t.Context(), StackSelector{})
+		if err != nil {
+			t.Fatalf("ResolveStacks failed: %v", err)
+		}
+		assertSliceEqual(t, stackNames(stacks), []string{"web", "db"})
+	})
+}
+
+func TestStackManagerEnableDisable(t *testing.T) {
+	newManager := func(t *testing.T) (*StackManager, *MockDockerExecutor, string) {
+		t.Helper()
+		dir := t.TempDir()
+		mustMkdir(t, filepath.Join(dir, "stacks", "01-web"))
+		mustMkdir(t, filepath.Join(dir, "stacks", "02-db"))
+
+		mock := &MockDockerExecutor{}
+		manager, _ := newTestManager(t, dir, nil, mock)
+		return manager, mock, dir
+	}
+
+	t.Run("disable creates marker and enable removes it", func(t *testing.T) {
+		manager, _, dir := newManager(t)
+		marker := filepath.Join(dir, "stacks", "01-web", disabledMarker)
+
+		if err := performAction(t, manager, "disable", "web"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := os.Stat(marker); err != nil {
+			t.Fatalf("Expected marker file: %v", err)
+		}
+
+		stacks, err := manager.ResolveStacks(t.Context(), StackSelector{Include: []string{"web"}})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if stacks[0].Enabled {
+			t.Error("Expected stack to be disabled")
+		}
+
+		if err := performAction(t, manager, "enable", "web"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := os.Stat(marker); !os.IsNotExist(err) {
+			t.Errorf("Expected marker file to be removed, got: %v", err)
+		}
+	})
+
+	t.Run("bulk start skips disabled stacks", func(t *testing.T) {
+		manager, mock, dir := newManager(t)
+		writeFile(t, filepath.Join(dir, "stacks", "01-web"), disabledMarker, "")
+
+		if err := performAction(t, manager, "start"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, call := range mock.RunCalls {
+			if slices.Contains(call, "web") {
+				t.Errorf("Disabled stack was started: %v", call)
+			}
+		}
+		if len(mock.RunCalls) != 1 {
+			t.Errorf("Expected 1 compose call, got %d", len(mock.RunCalls))
+		}
+	})
+
+	t.Run("explicit start runs disabled stack", func(t *testing.T) {
+		manager, mock, dir := newManager(t)
+		writeFile(t, filepath.Join(dir, "stacks", "01-web"), disabledMarker, "")
+
+		if err := performAction(t, manager, "start", "web"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(mock.RunCalls) != 1 || !slices.Contains(mock.RunCalls[0], "web") {
+			t.Errorf("Expected disabled stack to be started, got: %v", mock.RunCalls)
+		}
+	})
+
+	t.Run("dry-run leaves marker untouched", func(t *testing.T) {
+		_, _, dir := newManager(t)
+		manager := NewStackManager(dir, &Config{}, newTestLogger(t), true)
+
+		if err := performAction(t, manager, "disable", "web"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "stacks", "01-web", disabledMarker)); !os.IsNotExist(err) {
+			t.Errorf("Expected no marker file in dry-run, got: %v", err)
+		}
+	})
+}
+
+func TestStackManagerCompose(t *testing.T) {
+	dir := t.TempDir()
+	mustMkdir(t, filepath.Join(dir, "stacks", "01-web"))
+	mustMkdir(t, filepath.Join(dir, "stacks", "02-api"))
+
+	mock := &MockDockerExecutor{}
+	manager, _ := newTestManager(t, dir, nil, mock)
+	manager.SetComposeArgs([]string{"pull"})
+
+	t.Run("runs for a single stack", func(t *testing.T) {
+		if err := performAction(t, manager, "compose", "api"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(mock.RunCalls) != 1 || !slices.Contains(mock.RunCalls[0], "api") {
+			t.Errorf("Expected compose call for api, got %v", mock.RunCalls)
+		}
+	})
+
+	t.Run("rejects several stacks", func(t *testing.T) {
+		err := performAction(t, manager, "compose", "*")
+		if err == nil || !strings.Contains(err.Error(), "single stack") {
+			t.Errorf("Expected single stack error, got: %v", err)
+		}
+	})
+}
+
+func TestStackManagerJSONLogStack(t *testing.T) {
+	dir := t.TempDir()
+	stackDir := filepath.Join(dir, "stacks", "01-web")
+	mustMkdir(t, stackDir)
+	writeFile(t, stackDir, "config.yaml", "hooks:\n  pre-start:\n    - command: \"true\"\n")
+
+	manager, _ := newTestManager(t, dir, nil, &MockDockerExecutor{})
+	if err := manager.logger.SetFormat(LogFormatJSON); err != nil {
+		t.Fatalf("SetFormat failed: %v", err)
+	}
+
+	if err := performAction(t, manager, "start", "web"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(manager.logger.file.path)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	var checked int
+	for line := range strings.Lines(string(content)) {
+		var entry struct {
+			Message string `json:"message"`
+			Stack   string `json:"stack"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Invalid JSON entry %q: %v", line, err)
+		}
+		if !strings.Contains(entry.Message, "web") && !strings.Contains(entry.Message, "hook") {
+			continue
+		}
+		checked++
+		if entry.Stack != "web" {
+			t.Errorf("Expected stack web on entry: %s", line)
+		}
+	}
+	// Starting, the hook and the result at least
+	if checked < 3 {
+		t.Errorf("Expected entries about the stack, got:\n%s", content)
+	}
+}
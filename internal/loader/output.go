@@ -0,0 +1,100 @@
+package loader
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// prefixWriter writes complete lines to an underlying writer, prefixing each
+// with a label. Writers sharing a mutex never interleave within a line, which
+// keeps the output of concurrently processed stacks readable.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix []byte
+	buf    []byte
+}
+
+// newPrefixWriter creates a writer that prefixes lines written to out.
+func newPrefixWriter(out io.Writer, mu *sync.Mutex, prefix string) *prefixWriter {
+	return &prefixWriter{
+		mu:     mu,
+		out:    out,
+		prefix: []byte(prefix),
+	}
+}
+
+// Write buffers p and emits every complete line it contains.
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+
+	for {
+		idx := bytes.IndexByte(w.buf, '\n')
+		if idx == -1 {
+			break
+		}
+		if err := w.writeLine(w.buf[:idx+1]); err != nil {
+			return len(p), err
+		}
+		w.buf = w.buf[idx+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush emits any buffered partial line terminated with a newline.
+func (w *prefixWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := append(w.buf, '\n') //nolint:gocritic // Buffer is discarded after the final line
+	w.buf = nil
+	return w.writeLine(line)
+}
+
+func (w *prefixWriter) writeLine(line []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data := make([]byte, 0, len(w.prefix)+len(line))
+	data = append(data, w.prefix...)
+	data = append(data, line...)
+	_, err := w.out.Write(data)
+	return err
+}
+
+// prefixColors are the ANSI colors cycled through for stack prefixes.
+var prefixColors = []string{"36", "33", "32", "35", "34", "96", "93", "92", "95", "94"}
+
+// colorize wraps s in the ANSI color assigned to index i.
+func colorize(s string, i int) string {
+	return "\x1b[" + prefixColors[i%len(prefixColors)] + "m" + s + "\x1b[0m"
+}
+
+// colorEnabled reports whether output written to w should be colored: w must
+// be a terminal and NO_COLOR must not be set.
+func colorEnabled(w io.Writer) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return isTerminal(w)
+}
+
+// isTerminal reports whether v is a file connected to a terminal. Other
+// character devices, such as /dev/null, are not terminals: only a terminal
+// has terminal attributes.
+func isTerminal(v any) bool {
+	f, ok := v.(*os.File)
+	if !ok {
+		return false
+	}
+
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TCGETS,
+		uintptr(unsafe.Pointer(&termios))) //nolint:gosec // ioctl requires a pointer to the termios struct
+	return errno == 0
+}
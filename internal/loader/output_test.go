@@ -0,0 +1,58 @@
+package loader
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	t.Run("prefixes complete lines", func(t *testing.T) {
+		var buf bytes.Buffer
+		var mu sync.Mutex
+		w := newPrefixWriter(&buf, &mu, "web | ")
+
+		if _, err := w.Write([]byte("one\ntw")); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		if got := buf.String(); got != "web | one\n" {
+			t.Errorf("Unexpected output after first write: %q", got)
+		}
+
+		if _, err := w.Write([]byte("o\n")); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		if got := buf.String(); got != "web | one\nweb | two\n" {
+			t.Errorf("Unexpected output after second write: %q", got)
+		}
+	})
+
+	t.Run("flush terminates partial line", func(t *testing.T) {
+		var buf bytes.Buffer
+		var mu sync.Mutex
+		w := newPrefixWriter(&buf, &mu, "db | ")
+
+		if _, err := w.Write([]byte("partial")); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+		if got := buf.String(); got != "db | partial\n" {
+			t.Errorf("Unexpected output: %q", got)
+		}
+	})
+
+	t.Run("flush without data writes nothing", func(t *testing.T) {
+		var buf bytes.Buffer
+		var mu sync.Mutex
+		w := newPrefixWriter(&buf, &mu, "db | ")
+
+		if err := w.Flush(); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("Expected no output, got %q", buf.String())
+		}
+	})
+}
@@ -1,13 +1,19 @@
 package loader
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
+	"strings"
 )
 
+// disabledMarker is the file that marks a stack as disabled.
+const disabledMarker = ".disabled"
+
 // StackRepository handles stack discovery and retrieval.
 type StackRepository struct {
 	logger    *Logger
@@ -25,7 +31,7 @@ func NewStackRepository(baseDir string, logger *Logger, compose *ComposeClient)
 }
 
 // FindAll discovers all stacks in the stacks directory.
-func (r *StackRepository) FindAll() ([]*Stack, error) {
+func (r *StackRepository) FindAll(ctx context.Context) ([]*Stack, error) {
 	if _, err := os.Stat(r.stacksDir); os.IsNotExist(err) {
 		return nil, fmt.Errorf("stacks directory does not exist: %s", r.stacksDir)
 	}
@@ -55,9 +61,15 @@ func (r *StackRepository) FindAll() ([]*Stack, error) {
 			continue
 		}
 
+		stackDir := filepath.Join(r.stacksDir, name)
 		stacks = append(stacks, &Stack{
-			Name: stackName,
-			Dir:  filepath.Join(r.stacksDir, name),
+			Name:       stackName,
+			Dir:        stackDir,
+			Order:      strings.SplitN(name, "-", 2)[0],
+			Containers: map[string]int{},
+			DependsOn:  []string{},
+			Tags:       []string{},
+			Enabled:    !fileExists(filepath.Join(stackDir, disabledMarker)),
 		})
 	}
 
@@ -66,15 +78,18 @@ func (r *StackRepository) FindAll() ([]*Stack, error) {
 		return filepath.Base(stacks[i].Dir) < filepath.Base(stacks[j].Dir)
 	})
 
+	// Load per-stack settings needed for ordering and selection
+	r.populateConfigs(stacks)
+
 	// Populate status for all stacks
-	r.populateStatuses(stacks)
+	r.populateStatuses(ctx, stacks)
 
 	return stacks, nil
 }
 
 // FindByName finds a stack by name or directory name.
-func (r *StackRepository) FindByName(name string) (*Stack, error) {
-	stacks, err := r.FindAll()
+func (r *StackRepository) FindByName(ctx context.Context, name string) (*Stack, error) {
+	stacks, err := r.FindAll(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -88,12 +103,106 @@ func (r *StackRepository) FindByName(name string) (*Stack, error) {
 	return nil, fmt.Errorf("stack not found: %s", name)
 }
 
-func (r *StackRepository) populateStatuses(stacks []*Stack) {
-	statuses := r.compose.GetProjectStatuses()
+// Select returns the stacks matching selector, keeping their order in stacks.
+// Every include entry must match at least one stack, before tags and
+// exclusions are applied.
+func (r *StackRepository) Select(stacks []*Stack, selector StackSelector) ([]*Stack, error) {
+	if err := selector.Validate(); err != nil {
+		return nil, err
+	}
+
+	var tagExpr TagExpr
+	if selector.Tags != "" {
+		//nolint:errcheck // Expression is validated above
+		tagExpr, _ = ParseTagExpr(selector.Tags)
+	}
+
+	for _, pattern := range selector.Include {
+		if !slices.ContainsFunc(stacks, func(stack *Stack) bool { return matchStack(stack, pattern) }) {
+			if isGlob(pattern) || rangePattern.MatchString(pattern) {
+				return nil, fmt.Errorf("no stacks match: %s", pattern)
+			}
+			return nil, fmt.Errorf("stack not found: %s", pattern)
+		}
+	}
+
+	selected := make([]*Stack, 0, len(stacks))
+	for _, stack := range stacks {
+		if len(selector.Include) > 0 && !matchesAny(stack, selector.Include) {
+			continue
+		}
+		if matchesAny(stack, selector.Exclude) {
+			r.logger.Debug("Excluding stack: %s", stack.Name)
+			continue
+		}
+		if tagExpr != nil && !tagExpr.Match(stack.Tags) {
+			continue
+		}
+		stack.explicit = slices.ContainsFunc(selector.Include, func(name string) bool {
+			return name == stack.Name || name == filepath.Base(stack.Dir)
+		})
+		selected = append(selected, stack)
+	}
+
+	return selected, nil
+}
+
+func matchesAny(stack *Stack, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matchStack(stack, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetEnabled enables or disables a stack by removing or creating its marker file.
+func (r *StackRepository) SetEnabled(stack *Stack, enabled bool) error {
+	marker := filepath.Join(stack.Dir, disabledMarker)
+
+	if enabled {
+		if err := os.Remove(marker); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("enabling stack %s: %w", stack.Name, err)
+		}
+	} else {
+		//nolint:gosec // Marker file is created in a trusted stack directory
+		if err := os.WriteFile(marker, nil, 0o644); err != nil {
+			return fmt.Errorf("disabling stack %s: %w", stack.Name, err)
+		}
+	}
+
+	stack.Enabled = enabled
+	return nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func (r *StackRepository) populateConfigs(stacks []*Stack) {
+	for _, stack := range stacks {
+		stackConfig, err := LoadStackConfig(stack.Dir)
+		if err != nil {
+			r.logger.Warning("Failed to load stack config for %s: %v", stack.Name, err)
+			continue
+		}
+		if len(stackConfig.DependsOn) > 0 {
+			stack.DependsOn = stackConfig.DependsOn
+		}
+		if len(stackConfig.Tags) > 0 {
+			stack.Tags = stackConfig.Tags
+		}
+	}
+}
+
+func (r *StackRepository) populateStatuses(ctx context.Context, stacks []*Stack) {
+	projects := r.compose.GetProjects(ctx)
 	for _, stack := range stacks {
-		stack.Status = statuses[stack.Name]
-		if stack.Status == "" {
-			stack.Status = StackStatusDown
+		stack.Status = StackStatusDown
+		if project, ok := projects[stack.Name]; ok {
+			stack.Status = project.Status
+			stack.Containers = project.Containers
 		}
 	}
 }
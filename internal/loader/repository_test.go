@@ -22,7 +22,7 @@ func TestStackRepositoryFindAll(t *testing.T) {
 		compose := NewComposeClient(mock, newTestLogger(t), &Config{})
 		repo := NewStackRepository(dir, newTestLogger(t), compose)
 
-		stacks, err := repo.FindAll()
+		stacks, err := repo.FindAll(t.Context())
 		if err != nil {
 			t.Fatalf("FindAll failed: %v", err)
 		}
@@ -44,7 +44,7 @@ func TestStackRepositoryFindAll(t *testing.T) {
 		compose := NewComposeClient(mock, newTestLogger(t), &Config{})
 		repo := NewStackRepository(dir, newTestLogger(t), compose)
 
-		_, err := repo.FindAll()
+		_, err := repo.FindAll(t.Context())
 		if err == nil {
 			t.Error("Expected error for missing stacks directory")
 		}
@@ -62,7 +62,7 @@ func TestStackRepositoryFindAll(t *testing.T) {
 		compose := NewComposeClient(mock, newTestLogger(t), &Config{})
 		repo := NewStackRepository(dir, newTestLogger(t), compose)
 
-		stacks, err := repo.FindAll()
+		stacks, err := repo.FindAll(t.Context())
 		if err != nil {
 			t.Fatalf("FindAll failed: %v", err)
 		}
@@ -72,6 +72,26 @@ func TestStackRepositoryFindAll(t *testing.T) {
 	})
 }
 
+func TestStackRepositoryDependencies(t *testing.T) {
+	t.Run("loads depends-on and tags from stack config", func(t *testing.T) {
+		dir := t.TempDir()
+		stacksDir := filepath.Join(dir, "stacks")
+		mustMkdir(t, filepath.Join(stacksDir, "01-web"))
+		writeFile(t, filepath.Join(stacksDir, "01-web"), "config.yaml", "depends-on: [database]\ntags: [media]\n")
+
+		mock := &MockDockerExecutor{RunQuietOut: []byte("[]")}
+		compose := NewComposeClient(mock, newTestLogger(t), &Config{})
+		repo := NewStackRepository(dir, newTestLogger(t), compose)
+
+		stacks, err := repo.FindAll(t.Context())
+		if err != nil {
+			t.Fatalf("FindAll failed: %v", err)
+		}
+		assertSliceEqual(t, stacks[0].DependsOn, []string{"database"})
+		assertSliceEqual(t, stacks[0].Tags, []string{"media"})
+	})
+}
+
 func TestStackRepositoryFindByName(t *testing.T) {
 	t.Run("finds by stack name", func(t *testing.T) {
 		dir := t.TempDir()
@@ -82,7 +102,7 @@ func TestStackRepositoryFindByName(t *testing.T) {
 		compose := NewComposeClient(mock, newTestLogger(t), &Config{})
 		repo := NewStackRepository(dir, newTestLogger(t), compose)
 
-		stack, err := repo.FindByName(testStackName)
+		stack, err := repo.FindByName(t.Context(), testStackName)
 		if err != nil {
 			t.Fatalf("FindByName failed: %v", err)
 		}
@@ -100,7 +120,7 @@ func TestStackRepositoryFindByName(t *testing.T) {
 		compose := NewComposeClient(mock, newTestLogger(t), &Config{})
 		repo := NewStackRepository(dir, newTestLogger(t), compose)
 
-		stack, err := repo.FindByName("01-web")
+		stack, err := repo.FindByName(t.Context(), "01-web")
 		if err != nil {
 			t.Fatalf("FindByName failed: %v", err)
 		}
@@ -118,7 +138,7 @@ func TestStackRepositoryFindByName(t *testing.T) {
 		compose := NewComposeClient(mock, newTestLogger(t), &Config{})
 		repo := NewStackRepository(dir, newTestLogger(t), compose)
 
-		_, err := repo.FindByName("unknown")
+		_, err := repo.FindByName(t.Context(), "unknown")
 		if err == nil {
 			t.Error("Expected error for unknown stack")
 		}
@@ -140,7 +160,7 @@ func TestStackRepositoryStatus(t *testing.T) {
 		compose := NewComposeClient(mock, newTestLogger(t), &Config{})
 		repo := NewStackRepository(dir, newTestLogger(t), compose)
 
-		stacks, err := repo.FindAll()
+		stacks, err := repo.FindAll(t.Context())
 		if err != nil {
 			t.Fatalf("FindAll failed: %v", err)
 		}
@@ -149,3 +169,80 @@ func TestStackRepositoryStatus(t *testing.T) {
 		}
 	})
 }
+
+func TestStackRepositorySelect(t *testing.T) {
+	stacks := []*Stack{
+		{Name: "proxy", Dir: "/stacks/10-proxy"},
+		{Name: "media-plex", Dir: "/stacks/20-media-plex"},
+		{Name: "media-sonarr", Dir: "/stacks/30-media-sonarr"},
+		{Name: "db", Dir: "/stacks/40-db"},
+	}
+	repo := NewStackRepository(t.TempDir(), newTestLogger(t), nil)
+
+	tests := []struct {
+		name     string
+		selector StackSelector
+		expected []string
+	}{
+		{"multiple names keep stack order", StackSelector{Include: []string{"db", "proxy"}}, []string{"proxy", "db"}},
+		{"glob", StackSelector{Include: []string{"media-*"}}, []string{"media-plex", "media-sonarr"}},
+		{"range", StackSelector{Include: []string{"20..40"}}, []string{"media-plex", "media-sonarr", "db"}},
+		{"exclude only", StackSelector{Exclude: []string{"media-*"}}, []string{"proxy", "db"}},
+		{"include and exclude", StackSelector{Include: []string{"10..30"}, Exclude: []string{"media-sonarr"}},
+			[]string{"proxy", "media-plex"}},
+		{"overlapping patterns", StackSelector{Include: []string{"media-*", "media-plex"}},
+			[]string{"media-plex", "media-sonarr"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := repo.Select(stacks, tt.selector)
+			if err != nil {
+				t.Fatalf("Select failed: %v", err)
+			}
+			assertSliceEqual(t, stackNames(selected), tt.expected)
+		})
+	}
+
+	t.Run("tag expression", func(t *testing.T) {
+		tagged := []*Stack{
+			{Name: "plex", Dir: "/stacks/10-plex", Tags: []string{"media", "critical"}},
+			{Name: "sonarr", Dir: "/stacks/20-sonarr", Tags: []string{"media"}},
+			{Name: "db", Dir: "/stacks/30-db", Tags: []string{"critical"}},
+		}
+
+		selected, err := repo.Select(tagged, StackSelector{Tags: "media && !critical"})
+		if err != nil {
+			t.Fatalf("Select failed: %v", err)
+		}
+		assertSliceEqual(t, stackNames(selected), []string{"sonarr"})
+
+		selected, err = repo.Select(tagged, StackSelector{Include: []string{"plex", "db"}, Tags: "!critical"})
+		if err != nil {
+			t.Fatalf("Select failed: %v", err)
+		}
+		if len(selected) != 0 {
+			t.Errorf("Expected no stacks, got %v", stackNames(selected))
+		}
+	})
+
+	t.Run("invalid tag expression returns error", func(t *testing.T) {
+		if _, err := repo.Select(stacks, StackSelector{Tags: "media &&"}); err == nil {
+			t.Error("Expected error for invalid tag expression")
+		}
+	})
+
+	t.Run("unknown name returns error", func(t *testing.T) {
+		_, err := repo.Select(stacks, StackSelector{Include: []string{"proxy", "unknown"}})
+		if err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("Expected 'not found' error, got: %v", err)
+		}
+	})
+
+	t.Run("unmatched glob returns error", func(t *testing.T) {
+		_, err := repo.Select(stacks, StackSelector{Include: []string{"cache-*"}})
+		if err == nil || !strings.Contains(err.Error(), "no stacks match") {
+			t.Errorf("Expected 'no stacks match' error, got: %v", err)
+		}
+	})
+}
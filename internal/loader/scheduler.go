@@ -0,0 +1,119 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RunScheduler runs an update of all stacks whenever the configured schedule
+// matches, until ctx is cancelled or the manager is interrupted. Stacks with auto-update: false are left
+// out, and no stack is updated once the maintenance window has closed.
+func (m *StackManager) RunScheduler(ctx context.Context) error {
+	schedule := m.config.Schedule
+	if schedule.Cron == "" {
+		return fmt.Errorf("no schedule configured (set schedule.cron in config.yaml)")
+	}
+
+	cron, err := ParseCron(schedule.Cron)
+	if err != nil {
+		return err
+	}
+	window, err := schedule.WindowDuration()
+	if err != nil {
+		return err
+	}
+
+	m.logger.Info("Scheduler started with schedule %q", schedule.Cron)
+
+	for {
+		next := cron.Next(time.Now())
+		if next.IsZero() {
+			return fmt.Errorf("schedule %q never matches", schedule.Cron)
+		}
+		m.logger.Info("Next scheduled update at %s", next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			m.logger.Info("Scheduler stopped")
+			return nil
+		case <-m.interrupt:
+			timer.Stop()
+			m.logger.Info("Scheduler stopped")
+			return nil
+		case <-timer.C:
+		}
+
+		var deadline time.Time
+		if window > 0 {
+			deadline = next.Add(window)
+		}
+		m.runScheduledUpdate(ctx, deadline)
+	}
+}
+
+// runScheduledUpdate updates the enabled stacks opted into automatic updates
+// in start order. Remaining stacks are skipped once the deadline has passed, unless it
+// is zero, or the run is interrupted. Failures are logged and do not stop the run.
+func (m *StackManager) runScheduledUpdate(ctx context.Context, deadline time.Time) {
+	m.logger.Info("Starting scheduled update")
+
+	if !m.dryRun {
+		opts := LockOptions{Wait: true}
+		if !deadline.IsZero() {
+			opts.Timeout = max(time.Until(deadline), time.Nanosecond)
+		}
+		lockCtx, cancel := m.interruptContext(ctx)
+		lock, err := AcquireRunLock(lockCtx, m.baseDir, "scheduler", nil, opts, m.logger)
+		cancel()
+		if err != nil {
+			m.logger.Error("Scheduled update failed: %v", err)
+			return
+		}
+		defer lock.Release()
+	}
+
+	stacks, err := m.ResolveStacks(ctx, StackSelector{})
+	if err == nil {
+		err = CheckDuplicates(stacks)
+	}
+	if err != nil {
+		m.logger.Error("Scheduled update failed: %v", err)
+		return
+	}
+
+	var succeeded, failed, skipped int
+	for i, stack := range stacks {
+		if m.interrupted(ctx) {
+			skipped += len(stacks) - i
+			m.logger.Warning("Scheduled update interrupted, skipping %d remaining stack(s)", len(stacks)-i)
+			break
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			skipped += len(stacks) - i
+			m.logger.Warning("Maintenance window closed, skipping %d remaining stack(s)", len(stacks)-i)
+			break
+		}
+
+		if !stack.Enabled {
+			m.logger.Info("Skipping disabled stack: %s", stack.Name)
+			skipped++
+			continue
+		}
+		if !m.loadStackConfig(stack).AutoUpdateEnabled() {
+			m.logger.Info("Skipping stack %s: auto-update is disabled", stack.Name)
+			skipped++
+			continue
+		}
+
+		if result := m.runStack(ctx, ActionUpdate, stack, nil, m.updateStack); result.Status == ResultFailed {
+			failed++
+		} else {
+			succeeded++
+		}
+	}
+
+	m.logger.Info("Scheduled update finished: %d succeeded, %d failed, %d skipped", succeeded, failed, skipped)
+}
@@ -0,0 +1,117 @@
+package loader
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunScheduledUpdate(t *testing.T) {
+	newManager := func(t *testing.T) (*StackManager, *MockDockerExecutor) {
+		t.Helper()
+		dir := t.TempDir()
+		mustMkdir(t, filepath.Join(dir, "stacks", "01-web"))
+		mustMkdir(t, filepath.Join(dir, "stacks", "02-db"))
+		writeFile(t, filepath.Join(dir, "stacks", "02-db"), "config.yaml", "auto-update: false")
+		mustMkdir(t, filepath.Join(dir, "stacks", "03-cache"))
+		writeFile(t, filepath.Join(dir, "stacks", "03-cache"), disabledMarker, "")
+
+		mock := newImageExecutor("running(2)", "sha256:new")
+		manager, _ := newTestManager(t, dir, nil, mock)
+		return manager, mock
+	}
+
+	pulled := func(mock *MockDockerExecutor) []string {
+		var names []string
+		for _, call := range mock.RunCalls {
+			if slices.Contains(call, "pull") {
+				names = append(names, call[4])
+			}
+		}
+		return names
+	}
+
+	t.Run("updates enabled stacks not opted out", func(t *testing.T) {
+		manager, mock := newManager(t)
+		manager.runScheduledUpdate(context.Background(), time.Now().Add(time.Hour))
+		assertSliceEqual(t, pulled(mock), []string{"web"})
+
+		last, err := manager.state.Last()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if record, ok := last["web"]; !ok || record.Action != ActionUpdate || record.Status != ResultSucceeded {
+			t.Errorf("Expected succeeded update recorded for web, got %v", last)
+		}
+		for _, skipped := range []string{"db", "cache"} {
+			if _, ok := last[skipped]; ok {
+				t.Errorf("Expected no record for skipped %s, got %v", skipped, last[skipped])
+			}
+		}
+	})
+
+	t.Run("skips stacks after the window closed", func(t *testing.T) {
+		manager, mock := newManager(t)
+		manager.runScheduledUpdate(context.Background(), time.Now().Add(-time.Minute))
+		if len(mock.RunCalls) != 0 {
+			t.Errorf("Expected no compose calls, got %v", mock.RunCalls)
+		}
+	})
+
+	t.Run("interrupt stops waiting for the lock", func(t *testing.T) {
+		manager, mock := newManager(t)
+		lock, err := AcquireRunLock(t.Context(), manager.baseDir, "start", nil, LockOptions{}, newTestLogger(t))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		defer lock.Release()
+
+		time.AfterFunc(50*time.Millisecond, manager.Interrupt)
+		start := time.Now()
+		manager.runScheduledUpdate(context.Background(), time.Time{})
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Waited %s for the lock after the interrupt", elapsed)
+		}
+		if len(mock.RunCalls) != 0 {
+			t.Errorf("Expected no compose calls, got %v", mock.RunCalls)
+		}
+	})
+
+	t.Run("zero deadline does not limit the update", func(t *testing.T) {
+		manager, mock := newManager(t)
+		manager.runScheduledUpdate(context.Background(), time.Time{})
+		assertSliceEqual(t, pulled(mock), []string{"web"})
+	})
+}
+
+func TestRunScheduler(t *testing.T) {
+	t.Run("requires a schedule", func(t *testing.T) {
+		manager := NewStackManager(t.TempDir(), &Config{}, newTestLogger(t), true)
+		err := manager.RunScheduler(context.Background())
+		if err == nil || !strings.Contains(err.Error(), "no schedule") {
+			t.Errorf("Expected missing schedule error, got: %v", err)
+		}
+	})
+
+	t.Run("rejects invalid window", func(t *testing.T) {
+		config := &Config{Schedule: Schedule{Cron: "@daily", Window: "soon"}}
+		manager := NewStackManager(t.TempDir(), config, newTestLogger(t), true)
+		if err := manager.RunScheduler(context.Background()); err == nil {
+			t.Error("Expected error for invalid window")
+		}
+	})
+
+	t.Run("stops when context is cancelled", func(t *testing.T) {
+		config := &Config{Schedule: Schedule{Cron: "@yearly"}}
+		manager := NewStackManager(t.TempDir(), config, newTestLogger(t), true)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := manager.RunScheduler(ctx); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package loader
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// rangePattern matches order prefix ranges such as "10..30", "..20" or "50..".
+var rangePattern = regexp.MustCompile(`^(\d*)\.\.(\d*)$`)
+
+// StackSelector selects stacks by name, glob pattern, order prefix range or tags.
+// Include entries may be a stack or directory name, a glob such as "media-*",
+// or an inclusive prefix range such as "10..30". An empty Include selects all
+// stacks. Exclude entries use the same syntax. Tags is an optional tag
+// expression (see ParseTagExpr) that selected stacks must also match.
+type StackSelector struct {
+	Tags    string
+	Include []string
+	Exclude []string
+}
+
+// IsEmpty reports whether the selector targets all stacks.
+func (s StackSelector) IsEmpty() bool {
+	return len(s.Include) == 0 && len(s.Exclude) == 0 && s.Tags == ""
+}
+
+// Validate checks that all patterns and the tag expression are well-formed.
+func (s StackSelector) Validate() error {
+	if s.Tags != "" {
+		if _, err := ParseTagExpr(s.Tags); err != nil {
+			return err
+		}
+	}
+
+	for _, pattern := range slices.Concat(s.Include, s.Exclude) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid stack pattern %q: %w", pattern, err)
+		}
+		if m := rangePattern.FindStringSubmatch(pattern); m != nil && m[1] == "" && m[2] == "" {
+			return fmt.Errorf("invalid stack range %q: at least one bound is required", pattern)
+		}
+	}
+	return nil
+}
+
+// matchStack reports whether a stack matches a selector pattern.
+func matchStack(stack *Stack, pattern string) bool {
+	dirName := filepath.Base(stack.Dir)
+	if pattern == stack.Name || pattern == dirName {
+		return true
+	}
+
+	if m := rangePattern.FindStringSubmatch(pattern); m != nil {
+		return inOrderRange(stackOrder(stack), m[1], m[2])
+	}
+
+	if isGlob(pattern) {
+		nameMatch, _ := path.Match(pattern, stack.Name) //nolint:errcheck // Patterns are validated beforehand
+		dirMatch, _ := path.Match(pattern, dirName)     //nolint:errcheck // Patterns are validated beforehand
+		return nameMatch || dirMatch
+	}
+
+	return false
+}
+
+// inOrderRange reports whether order lies within the inclusive bounds.
+// An empty bound is open.
+func inOrderRange(order, low, high string) bool {
+	value, err := strconv.Atoi(order)
+	if err != nil {
+		return false
+	}
+	if low != "" {
+		if bound, err := strconv.Atoi(low); err != nil || value < bound {
+			return false
+		}
+	}
+	if high != "" {
+		if bound, err := strconv.Atoi(high); err != nil || value > bound {
+			return false
+		}
+	}
+	return true
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
@@ -0,0 +1,56 @@
+package loader
+
+import "testing"
+
+func TestMatchStack(t *testing.T) {
+	stack := &Stack{Name: "media-plex", Dir: "/stacks/20-media-plex"}
+
+	tests := []struct {
+		name     string
+		pattern  string
+		expected bool
+	}{
+		{"stack name", "media-plex", true},
+		{"directory name", "20-media-plex", true},
+		{"other name", "plex", false},
+		{"glob on name", "media-*", true},
+		{"glob on directory", "2?-*", true},
+		{"non-matching glob", "db-*", false},
+		{"range containing order", "10..30", true},
+		{"range bound inclusive", "20..20", true},
+		{"range below order", "30..40", false},
+		{"open lower bound", "..20", true},
+		{"open upper bound", "21..", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := matchStack(stack, tt.pattern); result != tt.expected {
+				t.Errorf("matchStack(%q) = %v, want %v", tt.pattern, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStackSelectorValidate(t *testing.T) {
+	valid := StackSelector{Include: []string{"web", "media-*", "10..30"}, Exclude: []string{"..05"}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	for _, pattern := range []string{"media-[", ".."} {
+		selector := StackSelector{Include: []string{pattern}}
+		if err := selector.Validate(); err == nil {
+			t.Errorf("Expected error for pattern %q", pattern)
+		}
+	}
+}
+
+func TestStackSelectorIsEmpty(t *testing.T) {
+	if !(StackSelector{}).IsEmpty() {
+		t.Error("Expected empty selector")
+	}
+	if (StackSelector{Exclude: []string{"web"}}).IsEmpty() {
+		t.Error("Selector with exclusions is not empty")
+	}
+}
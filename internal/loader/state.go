@@ -0,0 +1,172 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// historyLimit is the number of action records kept per stack.
+const historyLimit = 20
+
+// ActionRecord describes an action performed on a stack.
+type ActionRecord struct {
+	Time   time.Time    `json:"time" yaml:"time"`
+	Action Action       `json:"action" yaml:"action"`
+	Status ResultStatus `json:"status" yaml:"status"`
+	Error  string       `json:"error,omitempty" yaml:"error,omitempty"`
+	// User is the user who ran composectl
+	User    string `json:"user,omitempty" yaml:"user,omitempty"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// Duration is in nanoseconds
+	Duration time.Duration `json:"duration" yaml:"duration"`
+}
+
+// StateStore persists the recent action history of stacks in a JSON file.
+// Updates from concurrent composectl processes are serialized with a file lock.
+type StateStore struct {
+	path string
+}
+
+// NewStateStore creates a state store in the state directory of baseDir.
+func NewStateStore(baseDir string) *StateStore {
+	return &StateStore{path: filepath.Join(baseDir, stateDir, "state.json")}
+}
+
+// Record appends records to the history of their stacks, keeping the most
+// recent historyLimit records of each stack.
+func (s *StateStore) Record(records map[string]ActionRecord) error {
+	return withFileLock(s.path, func() error {
+		history, err := readJSONFile[map[string][]ActionRecord](s.path)
+		if err != nil {
+			return err
+		}
+		if history == nil {
+			history = make(map[string][]ActionRecord)
+		}
+
+		for stack, record := range records {
+			entries := append(history[stack], record)
+			history[stack] = entries[max(0, len(entries)-historyLimit):]
+		}
+
+		return writeJSONFile(s.path, history)
+	})
+}
+
+// History returns the recorded actions of a stack, oldest first.
+func (s *StateStore) History(stack string) ([]ActionRecord, error) {
+	history, err := readJSONFile[map[string][]ActionRecord](s.path)
+	if err != nil {
+		return nil, err
+	}
+	return history[stack], nil
+}
+
+// Last returns the most recent action record of every stack.
+func (s *StateStore) Last() (map[string]ActionRecord, error) {
+	history, err := readJSONFile[map[string][]ActionRecord](s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	last := make(map[string]ActionRecord, len(history))
+	for stack, entries := range history {
+		if len(entries) > 0 {
+			last[stack] = entries[len(entries)-1]
+		}
+	}
+	return last, nil
+}
+
+// recordResults stores the outcome of an action for every stack it was
+// attempted on. Skipped stacks are not recorded.
+func (m *StackManager) recordResults(action Action, results []*StackResult) {
+	if m.dryRun {
+		return
+	}
+
+	now := time.Now()
+	runBy := currentUser()
+	records := make(map[string]ActionRecord)
+	for _, result := range results {
+		if result == nil || result.Status == ResultSkipped {
+			continue
+		}
+
+		record := ActionRecord{
+			Time:     now,
+			Action:   action,
+			Status:   result.Status,
+			User:     runBy,
+			Version:  m.version,
+			Duration: result.Duration,
+		}
+		if result.Err != nil {
+			record.Error = result.Err.Error()
+		}
+		records[result.Stack.Name] = record
+	}
+
+	if len(records) == 0 {
+		return
+	}
+	if err := m.state.Record(records); err != nil {
+		m.logger.Warning("Failed to record stack state: %v", err)
+	}
+}
+
+// currentUser returns the name of the user running composectl, preferring
+// the user who invoked sudo.
+func currentUser() string {
+	if name := os.Getenv("SUDO_USER"); name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// readJSONFile decodes the JSON file at path, returning the zero value if it
+// does not exist.
+func readJSONFile[T any](path string) (T, error) {
+	var v T
+
+	data, err := os.ReadFile(path) //nolint:gosec // State path is constructed from trusted base directory
+	if os.IsNotExist(err) {
+		return v, nil
+	}
+	if err != nil {
+		return v, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return v, nil
+}
+
+// withFileLock runs fn while holding an exclusive lock on a lock file next
+// to path, serializing read-modify-write cycles of composectl processes.
+func withFileLock(path string, fn func() error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gosec // State directory is not secret
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+
+	//nolint:gosec // Lock file path is constructed from trusted base directory
+	lock, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening lock file: %w", err)
+	}
+	defer lock.Close() //nolint:errcheck // Closing the file releases the lock
+
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("locking %s: %w", path, err)
+	}
+	return fn()
+}
@@ -0,0 +1,190 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStateStore(t *testing.T) {
+	t.Run("missing file has no history", func(t *testing.T) {
+		store := NewStateStore(t.TempDir())
+
+		history, err := store.History("web")
+		if err != nil || len(history) != 0 {
+			t.Errorf("Expected empty history, got %v (%v)", history, err)
+		}
+	})
+
+	t.Run("errors name the full path", func(t *testing.T) {
+		store := NewStateStore(t.TempDir())
+		mustMkdir(t, store.path)
+
+		if _, err := store.History("web"); err == nil || !strings.Contains(err.Error(), store.path) {
+			t.Errorf("Expected error naming %s, got %v", store.path, err)
+		}
+	})
+
+	t.Run("keeps the most recent records", func(t *testing.T) {
+		store := NewStateStore(t.TempDir())
+
+		for i := range historyLimit + 5 {
+			record := ActionRecord{Action: ActionStart, Status: ResultSucceeded, Duration: time.Duration(i)}
+			if err := store.Record(map[string]ActionRecord{"web": record}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+		if err := store.Record(map[string]ActionRecord{"db": {Action: ActionStop, Status: ResultFailed}}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		history, err := store.History("web")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(history) != historyLimit || history[0].Duration != 5 {
+			t.Errorf("Expected last %d records, got %d starting at %d", historyLimit, len(history), history[0].Duration)
+		}
+
+		last, err := store.Last()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if last["web"].Duration != historyLimit+4 || last["db"].Status != ResultFailed {
+			t.Errorf("Unexpected last records: %v", last)
+		}
+	})
+}
+
+func TestStackManagerRecordsState(t *testing.T) {
+	newManager := func(t *testing.T, mock *MockDockerExecutor) (*StackManager, *bytes.Buffer) {
+		t.Helper()
+		dir := t.TempDir()
+		mustMkdir(t, filepath.Join(dir, "stacks", "01-web"))
+		mustMkdir(t, filepath.Join(dir, "stacks", "02-db"))
+
+		manager, out := newTestManager(t, dir, nil, mock)
+		manager.SetVersion("1.2.3")
+		return manager, out
+	}
+
+	t.Run("records action results", func(t *testing.T) {
+		manager, _ := newManager(t, &MockDockerExecutor{RunError: errors.New("boom")})
+
+		if err := performAction(t, manager, "stop", "web"); err == nil {
+			t.Fatal("Expected error")
+		}
+
+		history, err := manager.state.History("web")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(history) != 1 {
+			t.Fatalf("Expected 1 record, got %v", history)
+		}
+		record := history[0]
+		if record.Action != ActionStop || record.Status != ResultFailed || record.Version != "1.2.3" ||
+			!strings.Contains(record.Error, "boom") {
+			t.Errorf("Unexpected record: %+v", record)
+		}
+		if db, _ := manager.state.History("db"); len(db) != 0 {
+			t.Errorf("Expected no record for untargeted stack, got %v", db)
+		}
+	})
+
+	t.Run("records each stack as it finishes", func(t *testing.T) {
+		manager, _ := newManager(t, &MockDockerExecutor{})
+		stacks, err := manager.ResolveStacks(t.Context(), StackSelector{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var recorded []string
+		fn := func(_ context.Context, stack *Stack, _ io.Writer) error {
+			last, err := manager.state.Last()
+			if err != nil {
+				return err
+			}
+			for name := range last {
+				recorded = append(recorded, name)
+			}
+			return nil
+		}
+		if err := manager.executeWithDuplicateCheck(t.Context(), ActionStart, stacks, fn); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		// web was recorded before db ran
+		assertSliceEqual(t, recorded, []string{"web"})
+	})
+
+	t.Run("dry-run records nothing", func(t *testing.T) {
+		manager, _ := newManager(t, &MockDockerExecutor{})
+		manager.dryRun = true
+
+		if err := performAction(t, manager, "start"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if last, _ := manager.state.Last(); len(last) != 0 {
+			t.Errorf("Expected no records, got %v", last)
+		}
+	})
+
+	t.Run("list shows last action", func(t *testing.T) {
+		manager, out := newManager(t, &MockDockerExecutor{})
+		if err := performAction(t, manager, "start", "web"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if err := performAction(t, manager, "list"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.Contains(out.String(), "LAST ACTION") || !strings.Contains(out.String(), "start (0s ago)") {
+			t.Errorf("Unexpected list output:\n%s", out.String())
+		}
+
+		out.Reset()
+		manager.SetOutputFormat(OutputJSON)
+		if err := performAction(t, manager, "list"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var stacks []map[string]any
+		if err := json.Unmarshal(out.Bytes(), &stacks); err != nil {
+			t.Fatalf("Invalid JSON output: %v", err)
+		}
+		last, ok := stacks[0]["last_action"].(map[string]any)
+		if !ok || last["action"] != "start" || last["status"] != "succeeded" {
+			t.Errorf("Unexpected last action: %v", stacks[0]["last_action"])
+		}
+		if _, ok := stacks[1]["last_action"]; ok {
+			t.Errorf("Expected no last action for db: %v", stacks[1])
+		}
+	})
+
+	t.Run("history lists actions newest first", func(t *testing.T) {
+		manager, out := newManager(t, &MockDockerExecutor{})
+		for _, action := range []string{"start", "stop"} {
+			if err := performAction(t, manager, action, "web"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+
+		out.Reset()
+		if err := performAction(t, manager, "history", "web"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		output := out.String()
+		if !strings.Contains(output, "1.2.3") || strings.Index(output, "stop") > strings.Index(output, "start") {
+			t.Errorf("Unexpected history output:\n%s", output)
+		}
+
+		if err := performAction(t, manager, "history", "*"); err == nil {
+			t.Error("Expected error for several stacks")
+		}
+	})
+}
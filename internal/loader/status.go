@@ -0,0 +1,96 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+const healthHealthy = "healthy"
+
+// showStatus queries per-service state of stacks and prints it.
+func (m *StackManager) showStatus(ctx context.Context, stacks []*Stack) error {
+	WarnDuplicates(stacks)
+
+	for _, stack := range stacks {
+		services, err := m.compose.Services(ctx, stack)
+		if err != nil {
+			m.logger.Warning("Failed to get status of stack %s: %v", stack.Name, err)
+			continue
+		}
+		stack.Services = services
+		stack.Status = aggregateStatus(services)
+	}
+
+	switch m.format {
+	case OutputJSON, OutputYAML:
+		return encodeOutput(m.out, m.format, stacks)
+	case OutputTable, OutputWide, "":
+		writeStatusTable(m.out, stacks)
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format: %s", m.format)
+	}
+}
+
+func writeStatusTable(out io.Writer, stacks []*Stack) {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "STACK\tSTATUS\tSERVICE\tSTATE\tHEALTH\tEXIT\tUPTIME\tIMAGE")
+	fmt.Fprintln(w, "-----\t------\t-------\t-----\t------\t----\t------\t-----")
+
+	for _, stack := range stacks {
+		if len(stack.Services) == 0 {
+			fmt.Fprintf(w, "%s\t%s\t-\t-\t-\t-\t-\t-\n", stack.Name, stack.Status)
+			continue
+		}
+
+		for _, service := range stack.Services {
+			health := service.Health
+			if health == "" {
+				health = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n", stack.Name, stack.Status,
+				service.Service, service.State, health, service.ExitCode, service.Status, service.Image)
+		}
+	}
+
+	//nolint:errcheck // Flush error is non-critical for display purposes
+	w.Flush()
+}
+
+// aggregateStatus derives the stack status from the state of its services.
+// A stack is degraded when only some of its containers run or any running
+// container reports an unhealthy state. Containers that exited with code 0,
+// such as one-shot init jobs, are ignored, like in serviceReady.
+func aggregateStatus(services []ServiceState) StackStatus {
+	if len(services) == 0 {
+		return StackStatusDown
+	}
+
+	running := 0
+	completed := 0
+	healthy := true
+	for _, service := range services {
+		if service.State == "exited" && service.ExitCode == 0 {
+			completed++
+			continue
+		}
+		if service.State != "running" {
+			continue
+		}
+		running++
+		if service.Health != "" && service.Health != healthHealthy {
+			healthy = false
+		}
+	}
+
+	switch {
+	case running == 0:
+		return StackStatusStopped
+	case running < len(services)-completed || !healthy:
+		return StackStatusDegraded
+	default:
+		return StackStatusRunning
+	}
+}
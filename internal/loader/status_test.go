@@ -0,0 +1,59 @@
+package loader
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAggregateStatus(t *testing.T) {
+	running := ServiceState{Service: "web", State: "running"}
+	exited := ServiceState{Service: "worker", State: "exited", ExitCode: 1}
+	unhealthy := ServiceState{Service: "db", State: "running", Health: "unhealthy"}
+	healthy := ServiceState{Service: "db", State: "running", Health: "healthy"}
+	completed := ServiceState{Service: "migrate", State: "exited", ExitCode: 0}
+
+	tests := []struct {
+		name     string
+		expected StackStatus
+		services []ServiceState
+	}{
+		{"no containers", StackStatusDown, nil},
+		{"all running", StackStatusRunning, []ServiceState{running, healthy}},
+		{"none running", StackStatusStopped, []ServiceState{exited}},
+		{"some running", StackStatusDegraded, []ServiceState{running, exited}},
+		{"unhealthy running", StackStatusDegraded, []ServiceState{running, unhealthy}},
+		{"completed job ignored", StackStatusRunning, []ServiceState{running, completed}},
+		{"only completed jobs", StackStatusStopped, []ServiceState{completed}},
+		{"failed job with completed job", StackStatusStopped, []ServiceState{exited, completed}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := aggregateStatus(tt.services); result != tt.expected {
+				t.Errorf("aggregateStatus() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestShowStatus(t *testing.T) {
+	dir := t.TempDir()
+	mustMkdir(t, filepath.Join(dir, "stacks", "10-web"))
+
+	manager, out := newTestManager(t, dir, nil, &MockDockerExecutor{
+		RunQuietOut: []byte(`{"Service":"web","Name":"web-1","State":"running","Status":"Up 2 hours","Image":"nginx"}
+{"Service":"worker","Name":"worker-1","State":"exited","Status":"Exited (1)","ExitCode":1}`),
+	})
+
+	if err := performAction(t, manager, "status"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	output := out.String()
+	for _, want := range []string{"degraded", "Up 2 hours", "nginx", "worker", "exited"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in output, got:\n%s", want, output)
+		}
+	}
+}
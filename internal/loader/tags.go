@@ -0,0 +1,192 @@
+package loader
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// TagExpr is a boolean expression over stack tags.
+type TagExpr interface {
+	Match(tags []string) bool
+}
+
+type tagName string
+
+func (t tagName) Match(tags []string) bool {
+	return slices.Contains(tags, string(t))
+}
+
+type tagNot struct {
+	expr TagExpr
+}
+
+func (t tagNot) Match(tags []string) bool {
+	return !t.expr.Match(tags)
+}
+
+type tagAnd []TagExpr
+
+func (t tagAnd) Match(tags []string) bool {
+	for _, expr := range t {
+		if !expr.Match(tags) {
+			return false
+		}
+	}
+	return true
+}
+
+type tagOr []TagExpr
+
+func (t tagOr) Match(tags []string) bool {
+	for _, expr := range t {
+		if expr.Match(tags) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTagExpr parses a tag expression such as "media", "!critical" or
+// "(media || tools) && !critical". A comma is shorthand for "||".
+// Operator precedence from highest to lowest is "!", "&&", "||".
+func ParseTagExpr(input string) (TagExpr, error) {
+	tokens, err := tokenizeTagExpr(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty tag expression")
+	}
+
+	parser := &tagParser{tokens: tokens}
+	expr, err := parser.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag expression %q: %w", input, err)
+	}
+	if parser.pos < len(tokens) {
+		return nil, fmt.Errorf("invalid tag expression %q: unexpected %q", input, tokens[parser.pos])
+	}
+
+	return expr, nil
+}
+
+func tokenizeTagExpr(input string) ([]string, error) {
+	var tokens []string
+
+	for i := 0; i < len(input); {
+		c := rune(input[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case strings.HasPrefix(input[i:], "&&"), strings.HasPrefix(input[i:], "||"):
+			tokens = append(tokens, input[i:i+2])
+			i += 2
+		case c == '!' || c == '(' || c == ')' || c == ',':
+			tokens = append(tokens, string(c))
+			i++
+		case isTagChar(c):
+			start := i
+			for i < len(input) && isTagChar(rune(input[i])) {
+				i++
+			}
+			tokens = append(tokens, input[start:i])
+		default:
+			return nil, fmt.Errorf("invalid character %q in tag expression %q", c, input)
+		}
+	}
+
+	return tokens, nil
+}
+
+func isTagChar(c rune) bool {
+	return c <= unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c) || c == '-' || c == '_' || c == '.')
+}
+
+type tagParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *tagParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *tagParser) parseOr() (TagExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	exprs := tagOr{left}
+	for p.peek() == "||" || p.peek() == "," {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, right)
+	}
+
+	if len(exprs) == 1 {
+		return left, nil
+	}
+	return exprs, nil
+}
+
+func (p *tagParser) parseAnd() (TagExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	exprs := tagAnd{left}
+	for p.peek() == "&&" {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, right)
+	}
+
+	if len(exprs) == 1 {
+		return left, nil
+	}
+	return exprs, nil
+}
+
+func (p *tagParser) parseUnary() (TagExpr, error) {
+	token := p.peek()
+	switch token {
+	case "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case "!":
+		p.pos++
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return tagNot{expr: expr}, nil
+	case "(":
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return expr, nil
+	case ")", "&&", "||", ",":
+		return nil, fmt.Errorf("unexpected %q", token)
+	default:
+		p.pos++
+		return tagName(token), nil
+	}
+}
@@ -0,0 +1,47 @@
+package loader
+
+import "testing"
+
+func TestParseTagExpr(t *testing.T) {
+	tags := []string{"media", "critical"}
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"media", true},
+		{"tools", false},
+		{"!critical", false},
+		{"!tools", true},
+		{"media && critical", true},
+		{"media && !critical", false},
+		{"tools || media", true},
+		{"tools, media", true},
+		{"tools || media && !critical", false},
+		{"(tools || media) && critical", true},
+		{"!(media && critical)", false},
+		{"!!media", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := ParseTagExpr(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseTagExpr(%q) failed: %v", tt.expr, err)
+			}
+			if result := expr.Match(tags); result != tt.expected {
+				t.Errorf("%q.Match(%v) = %v, want %v", tt.expr, tags, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseTagExprErrors(t *testing.T) {
+	for _, expr := range []string{"", "media &&", "(media", "media)", "media tools", "&& media", "media | tools", "!"} {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := ParseTagExpr(expr); err == nil {
+				t.Errorf("Expected error for %q", expr)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package loader
 
 import (
+	"bytes"
+	"context"
 	"os"
 	"path/filepath"
 	"slices"
@@ -16,15 +18,21 @@ type MockDockerExecutor struct {
 	RunQuietOut   []byte
 	RunError      error
 	RunQuietError error
+	// RunQuietFunc, if set, produces the output of RunQuiet instead of
+	// RunQuietOut and RunQuietError
+	RunQuietFunc func(args []string) ([]byte, error)
 }
 
-func (m *MockDockerExecutor) Run(args []string) error {
+func (m *MockDockerExecutor) Run(_ context.Context, args []string) error {
 	m.RunCalls = append(m.RunCalls, args)
 	return m.RunError
 }
 
-func (m *MockDockerExecutor) RunQuiet(args []string) ([]byte, error) {
+func (m *MockDockerExecutor) RunQuiet(_ context.Context, args []string) ([]byte, error) {
 	m.RunQuietCalls = append(m.RunQuietCalls, args)
+	if m.RunQuietFunc != nil {
+		return m.RunQuietFunc(args)
+	}
 	return m.RunQuietOut, m.RunQuietError
 }
 
@@ -45,6 +53,38 @@ func newTestLogger(t *testing.T) *Logger {
 	return logger
 }
 
+// newTestManager creates a stack manager for the base directory dir that runs
+// docker commands with executor and writes its output to the returned buffer.
+// A nil config is an empty configuration.
+func newTestManager(
+	t *testing.T, dir string, config *Config, executor DockerExecutor,
+) (*StackManager, *bytes.Buffer) {
+	t.Helper()
+	if config == nil {
+		config = &Config{}
+	}
+
+	logger := newTestLogger(t)
+	manager := NewStackManager(dir, config, logger, false)
+	manager.compose = NewComposeClient(executor, logger, config)
+	manager.repo.compose = manager.compose
+
+	var out bytes.Buffer
+	manager.out = &out
+	return manager, &out
+}
+
+// performAction performs action on the stacks selected by include, or on all
+// stacks if it is empty, as the commands do.
+func performAction(t *testing.T, manager *StackManager, action string, include ...string) error {
+	t.Helper()
+	stacks, err := manager.ResolveStacks(t.Context(), StackSelector{Include: include})
+	if err != nil {
+		return err
+	}
+	return manager.PerformAction(t.Context(), action, stacks)
+}
+
 func writeFile(t *testing.T, dir, name, content string) {
 	t.Helper()
 	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
@@ -1,5 +1,7 @@
 package loader
 
+import "time"
+
 // StackStatus represents the status of a Docker Compose stack.
 type StackStatus string
 
@@ -10,13 +12,99 @@ const (
 	StackStatusStopped StackStatus = "stopped"
 	// StackStatusDown indicates the stack has no containers.
 	StackStatusDown StackStatus = "down"
+	// StackStatusDegraded indicates some but not all containers are running or healthy.
+	StackStatusDegraded StackStatus = "degraded"
 )
 
 // Stack represents a Docker Compose stack.
+// Field tags define the schema of machine-readable list output.
+//
+//nolint:govet // Field order defines the output schema
 type Stack struct {
-	Name   string
-	Dir    string
-	Status StackStatus
+	Name   string      `json:"name" yaml:"name"`
+	Dir    string      `json:"dir" yaml:"dir"`
+	Order  string      `json:"order" yaml:"order"`
+	Status StackStatus `json:"status" yaml:"status"`
+	// Containers counts the containers of the stack per container state,
+	// e.g. {"running": 2, "exited": 1}, as reported by compose ls. The
+	// containers of each service are listed in Services by the status action.
+	Containers map[string]int `json:"containers" yaml:"containers"`
+	DependsOn  []string       `json:"depends_on" yaml:"depends_on"`
+	Tags       []string       `json:"tags" yaml:"tags"`
+	Services   []ServiceState `json:"services,omitempty" yaml:"services,omitempty"`
+	Enabled    bool           `json:"enabled" yaml:"enabled"`
+	LastAction *ActionRecord  `json:"last_action,omitempty" yaml:"last_action,omitempty"`
+	// explicit is set when the stack was named directly rather than matched
+	// by a pattern, range or tag expression
+	explicit bool
+}
+
+// ServiceState describes a single container of a stack as reported by compose ps.
+type ServiceState struct {
+	Service   string `json:"service" yaml:"service"`
+	Container string `json:"container" yaml:"container"`
+	State     string `json:"state" yaml:"state"`
+	Health    string `json:"health" yaml:"health"`
+	Status    string `json:"status" yaml:"status"`
+	Image     string `json:"image" yaml:"image"`
+	ExitCode  int    `json:"exit_code" yaml:"exit_code"`
+}
+
+// OutputFormat represents the output format of the list command.
+type OutputFormat string
+
+// Output formats for listing stacks.
+const (
+	OutputTable OutputFormat = "table"
+	OutputWide  OutputFormat = "wide"
+	OutputJSON  OutputFormat = "json"
+	OutputYAML  OutputFormat = "yaml"
+)
+
+// IsValid checks if the output format is supported.
+func (f OutputFormat) IsValid() bool {
+	switch f {
+	case OutputTable, OutputWide, OutputJSON, OutputYAML:
+		return true
+	default:
+		return false
+	}
+}
+
+// LogFormat is the format of log file entries.
+type LogFormat string
+
+// Log formats.
+const (
+	// LogFormatText writes "[timestamp] LEVEL: message" lines. This is the default.
+	LogFormatText LogFormat = "text"
+	// LogFormatJSON writes one JSON object per line.
+	LogFormatJSON LogFormat = "json"
+)
+
+// IsValid checks if the log format is supported.
+func (f LogFormat) IsValid() bool {
+	return f == LogFormatText || f == LogFormatJSON
+}
+
+// ResultStatus represents the outcome of an action on a single stack.
+type ResultStatus string
+
+const (
+	// ResultSucceeded indicates the action completed successfully.
+	ResultSucceeded ResultStatus = "succeeded"
+	// ResultFailed indicates the action returned an error.
+	ResultFailed ResultStatus = "failed"
+	// ResultSkipped indicates the action was not attempted.
+	ResultSkipped ResultStatus = "skipped"
+)
+
+// StackResult records the outcome of an action on a single stack.
+type StackResult struct {
+	Stack    *Stack
+	Err      error
+	Status   ResultStatus
+	Duration time.Duration
 }
 
 // Action represents a stack operation.
@@ -24,18 +112,54 @@ type Action string
 
 // Action constants for stack operations.
 const (
-	ActionStart   Action = "start"
-	ActionStop    Action = "stop"
-	ActionRestart Action = "restart"
-	ActionReload  Action = "reload"
-	ActionDown    Action = "down"
-	ActionList    Action = "list"
+	ActionStart    Action = "start"
+	ActionStop     Action = "stop"
+	ActionRestart  Action = "restart"
+	ActionReload   Action = "reload"
+	ActionDown     Action = "down"
+	ActionList     Action = "list"
+	ActionStatus   Action = "status"
+	ActionLogs     Action = "logs"
+	ActionHistory  Action = "history"
+	ActionCompose  Action = "compose"
+	ActionEnable   Action = "enable"
+	ActionDisable  Action = "disable"
+	ActionUpdate   Action = "update"
+	ActionRollback Action = "rollback"
+
+	ActionCheckUpdates Action = "check-updates"
 )
 
+// ModifiesStacks reports whether the action starts, stops or removes containers.
+func (a Action) ModifiesStacks() bool {
+	switch a {
+	case ActionList, ActionStatus, ActionLogs, ActionHistory, ActionCompose, ActionEnable, ActionDisable,
+		ActionCheckUpdates:
+		return false
+	default:
+		return a.IsValid()
+	}
+}
+
+// TakesLock reports whether the action must hold the run lock on its stacks:
+// actions modifying stacks, check-updates, whose pulls must not race with an
+// update, compose, which can run any docker compose command, and enable and
+// disable, which change what a run without stack arguments starts.
+func (a Action) TakesLock() bool {
+	switch a {
+	case ActionCheckUpdates, ActionCompose, ActionEnable, ActionDisable:
+		return true
+	default:
+		return a.ModifiesStacks()
+	}
+}
+
 // IsValid checks if the action is a valid operation.
 func (a Action) IsValid() bool {
 	switch a {
-	case ActionStart, ActionStop, ActionRestart, ActionReload, ActionDown, ActionList:
+	case ActionStart, ActionStop, ActionRestart, ActionReload, ActionDown, ActionList, ActionStatus,
+		ActionLogs, ActionHistory, ActionCompose, ActionEnable, ActionDisable, ActionUpdate, ActionRollback,
+		ActionCheckUpdates:
 		return true
 	default:
 		return false
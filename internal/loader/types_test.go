@@ -34,6 +34,8 @@ func TestNormalizeStatus(t *testing.T) {
 	}{
 		{"running", "running", StackStatusRunning},
 		{"running with count", "running(2)", StackStatusRunning},
+		{"mixed states map to degraded", "running(2), exited(1)", StackStatusDegraded},
+		{"exited before running maps to degraded", "exited(1), running(1)", StackStatusDegraded},
 		{"exited maps to stopped", "exited", StackStatusStopped},
 		{"stopped", "stopped", StackStatusStopped},
 		{"unknown maps to down", "unknown", StackStatusDown},
@@ -51,7 +53,9 @@ func TestNormalizeStatus(t *testing.T) {
 }
 
 func TestActionIsValid(t *testing.T) {
-	validActions := []Action{ActionStart, ActionStop, ActionRestart, ActionReload, ActionDown, ActionList}
+	validActions := []Action{
+		ActionStart, ActionStop, ActionRestart, ActionReload, ActionDown, ActionList, ActionStatus,
+	}
 	for _, action := range validActions {
 		if !action.IsValid() {
 			t.Errorf("Expected %q to be valid", action)
@@ -65,3 +69,74 @@ func TestActionIsValid(t *testing.T) {
 		}
 	}
 }
+
+func TestOutputFormatIsValid(t *testing.T) {
+	for _, format := range []OutputFormat{OutputTable, OutputWide, OutputJSON, OutputYAML} {
+		if !format.IsValid() {
+			t.Errorf("Expected %q to be valid", format)
+		}
+	}
+
+	for _, format := range []OutputFormat{"xml", ""} {
+		if format.IsValid() {
+			t.Errorf("Expected %q to be invalid", format)
+		}
+	}
+}
+
+func TestParseContainerCounts(t *testing.T) {
+	tests := []struct {
+		expected map[string]int
+		name     string
+		input    string
+	}{
+		{map[string]int{"running": 1}, "single state", "running(1)"},
+		{map[string]int{"running": 2, "exited": 1}, "multiple states", "running(2), exited(1)"},
+		{map[string]int{}, "no counts", "running"},
+		{map[string]int{}, "empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseContainerCounts(tt.input)
+			if len(result) != len(tt.expected) {
+				t.Fatalf("parseContainerCounts(%q) = %v, want %v", tt.input, result, tt.expected)
+			}
+			for state, count := range tt.expected {
+				if result[state] != count {
+					t.Errorf("parseContainerCounts(%q)[%q] = %d, want %d", tt.input, state, result[state], count)
+				}
+			}
+		})
+	}
+}
+
+func TestActionTakesLock(t *testing.T) {
+	for _, action := range []Action{
+		ActionStart, ActionUpdate, ActionCheckUpdates, ActionCompose, ActionEnable, ActionDisable,
+	} {
+		if !action.TakesLock() {
+			t.Errorf("Expected %q to take the lock", action)
+		}
+	}
+
+	for _, action := range []Action{ActionList, ActionStatus, ActionLogs, "invalid"} {
+		if action.TakesLock() {
+			t.Errorf("Expected %q not to take the lock", action)
+		}
+	}
+}
+
+func TestActionModifiesStacks(t *testing.T) {
+	for _, action := range []Action{ActionStart, ActionStop, ActionRestart, ActionReload, ActionDown} {
+		if !action.ModifiesStacks() {
+			t.Errorf("Expected %q to modify stacks", action)
+		}
+	}
+
+	for _, action := range []Action{ActionList, ActionStatus, "invalid"} {
+		if action.ModifiesStacks() {
+			t.Errorf("Expected %q not to modify stacks", action)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// ImageUpdate describes a service whose containers run an older image than
+// the one its image reference resolves to.
+type ImageUpdate struct {
+	Service string
+	Image   string
+	// Current is the ID of the image the containers run
+	Current string
+	// Latest is the ID of the image the reference resolves to after a pull
+	Latest string
+}
+
+// updateStack pulls the images of a stack and recreates it if any of its
+// running services has a newer image. Stacks that are not running are only
+// pulled.
+func (m *StackManager) updateStack(ctx context.Context, stack *Stack, out io.Writer) error {
+	logger := m.stackLogger(stack)
+	logger.Console("==> Updating stack: %s", stack.Name)
+	logger.Info("Updating stack: %s", stack.Name)
+
+	stackConfig := m.loadStackConfig(stack)
+	compose := m.composeFor(stack, out)
+
+	updates, err := m.pullUpdates(ctx, stack, compose, stackConfig)
+	if err != nil {
+		return err
+	}
+	if len(updates) == 0 {
+		logger.Info("Stack %s is up to date", stack.Name)
+		return nil
+	}
+
+	for _, update := range updates {
+		logger.Info("Stack %s: service %s has a new image %s (%s -> %s)", stack.Name, update.Service,
+			update.Image, shortImageID(update.Current), shortImageID(update.Latest))
+	}
+
+	if stack.Status != StackStatusRunning && stack.Status != StackStatusDegraded {
+		logger.Info("Stack %s is %s, not recreating", stack.Name, stack.Status)
+		return nil
+	}
+
+	record := newImageRecord(updates)
+	if !m.dryRun {
+		if err := m.images.Save(stack.Name, record); err != nil {
+			return fmt.Errorf("recording images of stack %s: %w", stack.Name, err)
+		}
+	}
+
+	logger.Console("==> Recreating stack: %s", stack.Name)
+	if err := compose.Recreate(ctx, stack, stackConfig); err != nil {
+		return err
+	}
+	if !stackConfig.WaitHealthy || m.dryRun {
+		return nil
+	}
+
+	healthErr := m.waitHealthy(ctx, stack, compose, stackConfig.HealthTimeoutDuration())
+	if healthErr == nil {
+		return nil
+	}
+
+	logger.Error("Stack %s is not healthy after the update, rolling back: %v", stack.Name, healthErr)
+	if err := m.restoreImages(ctx, stack, compose, stackConfig, record); err != nil {
+		return fmt.Errorf("%w; rollback failed: %w", healthErr, err)
+	}
+	return fmt.Errorf("%w; rolled back to the previous images", healthErr)
+}
+
+// rollbackStack recreates a stack with the images recorded before its last update.
+func (m *StackManager) rollbackStack(ctx context.Context, stack *Stack, out io.Writer) error {
+	logger := m.stackLogger(stack)
+	logger.Console("==> Rolling back stack: %s", stack.Name)
+	logger.Info("Rolling back stack: %s", stack.Name)
+
+	record, err := m.images.Load(stack.Name)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return fmt.Errorf("no previous images recorded for stack %s", stack.Name)
+	}
+
+	stackConfig := m.loadStackConfig(stack)
+	compose := m.composeFor(stack, out)
+
+	if err := m.restoreImages(ctx, stack, compose, stackConfig, record); err != nil {
+		return err
+	}
+	if stackConfig.WaitHealthy && !m.dryRun {
+		return m.waitHealthy(ctx, stack, compose, stackConfig.HealthTimeoutDuration())
+	}
+	return nil
+}
+
+// restoreImages points the image references of a stack back at the recorded
+// images and recreates the stack with them.
+func (m *StackManager) restoreImages(
+	ctx context.Context, stack *Stack, compose *ComposeClient, stackConfig *StackConfig, record *ImageRecord,
+) error {
+	logger := m.stackLogger(stack)
+	for _, service := range record.Services {
+		logger.Info("Stack %s: restoring service %s to image %s (%s)", stack.Name, service.Service,
+			service.Image, shortImageID(service.ID))
+		if err := compose.TagImage(ctx, service.ID, service.Image); err != nil {
+			return fmt.Errorf("restoring image %s of service %s: %w", service.Image, service.Service, err)
+		}
+	}
+
+	return compose.Recreate(ctx, stack, stackConfig)
+}
+
+// checkUpdates pulls the images of stacks and reports services whose
+// containers run an older image. No container is recreated. A stack that
+// cannot be checked does not stop the others; the failed stacks are
+// reported after the updates found.
+func (m *StackManager) checkUpdates(ctx context.Context, stacks []*Stack) error {
+	updates := make(map[*Stack][]ImageUpdate)
+	var failed []string
+	for _, stack := range stacks {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		logger := m.stackLogger(stack)
+		logger.Info("Checking stack for updates: %s", stack.Name)
+
+		stackUpdates, err := m.pullUpdates(ctx, stack, m.composeFor(stack, nil), m.loadStackConfig(stack))
+		if err != nil {
+			logger.With(logKeyError, err.Error()).Error("%v", err)
+			failed = append(failed, stack.Name)
+			continue
+		}
+		if len(stackUpdates) > 0 {
+			updates[stack] = stackUpdates
+		}
+	}
+
+	switch {
+	case len(updates) > 0:
+		writeUpdateTable(m.out, stacks, updates)
+	case len(failed) == 0:
+		fmt.Fprintln(m.out, "All stacks are up to date")
+	case len(failed) < len(stacks):
+		fmt.Fprintln(m.out, "All other stacks are up to date")
+	}
+
+	if len(failed) > 0 {
+		return &StacksFailedError{Failed: failed}
+	}
+	return nil
+}
+
+// pullUpdates pulls the images of a stack and returns its services with newer images.
+func (m *StackManager) pullUpdates(
+	ctx context.Context, stack *Stack, compose *ComposeClient, stackConfig *StackConfig,
+) ([]ImageUpdate, error) {
+	if err := compose.Pull(ctx, stack, stackConfig); err != nil {
+		return nil, fmt.Errorf("pulling images of stack %s: %w", stack.Name, err)
+	}
+
+	updates, err := compose.ImageUpdates(ctx, stack)
+	if err != nil {
+		return nil, fmt.Errorf("checking images of stack %s: %w", stack.Name, err)
+	}
+	return updates, nil
+}
+
+func writeUpdateTable(out io.Writer, stacks []*Stack, updates map[*Stack][]ImageUpdate) {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "STACK\tSERVICE\tIMAGE\tCURRENT\tLATEST")
+	fmt.Fprintln(w, "-----\t-------\t-----\t-------\t------")
+
+	for _, stack := range stacks {
+		for _, update := range updates[stack] {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", stack.Name, update.Service, update.Image,
+				shortImageID(update.Current), shortImageID(update.Latest))
+		}
+	}
+
+	//nolint:errcheck // Flush error is non-critical for display purposes
+	w.Flush()
+}
+
+// shortImageID returns the first 12 hex digits of an image ID.
+func shortImageID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
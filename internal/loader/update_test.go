@@ -0,0 +1,264 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newImageExecutor returns a mock reporting the web service of every stack
+// as running sha256:old, while nginx:latest resolves to latest.
+func newImageExecutor(status, latest string) *MockDockerExecutor {
+	return &MockDockerExecutor{
+		RunQuietFunc: func(args []string) ([]byte, error) {
+			switch {
+			case slices.Contains(args, "ls"):
+				return []byte(`[{"Name":"web","Status":"` + status + `"}]`), nil
+			case slices.Contains(args, "ps"):
+				return []byte(`[{"Service":"web","Name":"web-1","Image":"nginx:latest","State":"running"},` +
+					`{"Service":"web","Name":"web-2","Image":"nginx:latest","State":"running"}]`), nil
+			case args[0] == "inspect":
+				return []byte("sha256:old\n"), nil
+			case args[0] == "image":
+				return []byte(latest + "\n"), nil
+			}
+			return nil, nil
+		},
+	}
+}
+
+func TestComposeClientImageUpdates(t *testing.T) {
+	stack := &Stack{Name: "web", Dir: "/stacks/01-web"}
+
+	t.Run("reports services with a newer image once", func(t *testing.T) {
+		client := NewComposeClient(newImageExecutor("running(2)", "sha256:new"), newTestLogger(t), &Config{})
+
+		updates, err := client.ImageUpdates(t.Context(), stack)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		want := []ImageUpdate{{Service: "web", Image: "nginx:latest", Current: "sha256:old", Latest: "sha256:new"}}
+		if !slices.Equal(updates, want) {
+			t.Errorf("Expected %v, got %v", want, updates)
+		}
+	})
+
+	t.Run("returns nothing when images match", func(t *testing.T) {
+		client := NewComposeClient(newImageExecutor("running(2)", "sha256:old"), newTestLogger(t), &Config{})
+
+		updates, err := client.ImageUpdates(t.Context(), stack)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(updates) != 0 {
+			t.Errorf("Expected no updates, got %v", updates)
+		}
+	})
+}
+
+func TestStackManagerUpdate(t *testing.T) {
+	newManager := func(t *testing.T, mock *MockDockerExecutor) (*StackManager, *bytes.Buffer) {
+		t.Helper()
+		dir := t.TempDir()
+		mustMkdir(t, filepath.Join(dir, "stacks", "01-web"))
+
+		return newTestManager(t, dir, &Config{UpArgs: []string{"--detach"}}, mock)
+	}
+
+	commands := func(mock *MockDockerExecutor) []string {
+		var cmds []string
+		for _, call := range mock.RunCalls {
+			cmds = append(cmds, call[5])
+		}
+		return cmds
+	}
+
+	t.Run("recreates running stack with new images", func(t *testing.T) {
+		mock := newImageExecutor("running(2)", "sha256:new")
+		manager, _ := newManager(t, mock)
+
+		if err := performAction(t, manager, "update"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		assertSliceEqual(t, commands(mock), []string{"pull", "up"})
+	})
+
+	t.Run("does not recreate up to date stack", func(t *testing.T) {
+		mock := newImageExecutor("running(2)", "sha256:old")
+		manager, _ := newManager(t, mock)
+
+		if err := performAction(t, manager, "update"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		assertSliceEqual(t, commands(mock), []string{"pull"})
+	})
+
+	t.Run("does not start stopped stack", func(t *testing.T) {
+		mock := newImageExecutor("exited(2)", "sha256:new")
+		manager, _ := newManager(t, mock)
+
+		if err := performAction(t, manager, "update"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		assertSliceEqual(t, commands(mock), []string{"pull"})
+	})
+
+	t.Run("skips disabled stack unless named", func(t *testing.T) {
+		mock := newImageExecutor("running(2)", "sha256:new")
+		manager, _ := newManager(t, mock)
+		writeFile(t, filepath.Join(manager.baseDir, "stacks", "01-web"), disabledMarker, "")
+
+		if err := performAction(t, manager, "update"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(mock.RunCalls) != 0 {
+			t.Errorf("Expected no compose calls for disabled stack, got %v", mock.RunCalls)
+		}
+
+		if err := performAction(t, manager, "update", "web"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		assertSliceEqual(t, commands(mock), []string{"pull", "up"})
+	})
+
+	t.Run("check-updates only reports", func(t *testing.T) {
+		mock := newImageExecutor("running(2)", "sha256:0123456789abcdef")
+		manager, out := newManager(t, mock)
+
+		if err := performAction(t, manager, "check-updates"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		assertSliceEqual(t, commands(mock), []string{"pull"})
+		if !strings.Contains(out.String(), "nginx:latest") || !strings.Contains(out.String(), "0123456789ab\n") {
+			t.Errorf("Unexpected output:\n%s", out.String())
+		}
+	})
+
+	t.Run("check-updates reports up to date stacks", func(t *testing.T) {
+		manager, out := newManager(t, newImageExecutor("running(2)", "sha256:old"))
+
+		if err := performAction(t, manager, "check-updates"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.Contains(out.String(), "up to date") {
+			t.Errorf("Unexpected output:\n%s", out.String())
+		}
+	})
+}
+
+// pullFailingExecutor fails the pull of the stack with the given project name.
+type pullFailingExecutor struct {
+	*MockDockerExecutor
+	project string
+}
+
+func (e *pullFailingExecutor) Run(ctx context.Context, args []string) error {
+	if slices.Contains(args, "pull") && slices.Contains(args, e.project) {
+		return errors.New("pull access denied")
+	}
+	return e.MockDockerExecutor.Run(ctx, args)
+}
+
+func TestStackManagerCheckUpdates(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"01-web", "02-db", "03-cache"} {
+		mustMkdir(t, filepath.Join(dir, "stacks", name))
+	}
+	writeFile(t, filepath.Join(dir, "stacks", "03-cache"), disabledMarker, "")
+
+	mock := newImageExecutor("running(2)", "sha256:new")
+	executor := &pullFailingExecutor{MockDockerExecutor: mock, project: "web"}
+	manager, out := newTestManager(t, dir, nil, executor)
+
+	err := performAction(t, manager, "check-updates")
+	var failedErr *StacksFailedError
+	if !errors.As(err, &failedErr) {
+		t.Fatalf("Expected StacksFailedError, got %v", err)
+	}
+	assertSliceEqual(t, failedErr.Failed, []string{"web"})
+
+	// The failed stack does not stop the others; the disabled one is skipped
+	var pulled []string
+	for _, call := range mock.RunCalls {
+		pulled = append(pulled, call[4])
+	}
+	assertSliceEqual(t, pulled, []string{"db"})
+	if !strings.Contains(out.String(), "db ") || strings.Contains(out.String(), "cache") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+}
+
+func TestStackManagerRollback(t *testing.T) {
+	newManager := func(t *testing.T, mock *MockDockerExecutor, stackConfig string) *StackManager {
+		t.Helper()
+		dir := t.TempDir()
+		mustMkdir(t, filepath.Join(dir, "stacks", "01-web"))
+		writeFile(t, filepath.Join(dir, "stacks", "01-web"), "config.yaml", stackConfig)
+
+		config := &Config{UpArgs: []string{"--detach", "--pull", "always"}}
+		manager, _ := newTestManager(t, dir, config, mock)
+		manager.healthInterval = time.Millisecond
+		return manager
+	}
+
+	t.Run("restores recorded images", func(t *testing.T) {
+		mock := &MockDockerExecutor{}
+		manager := newManager(t, mock, "")
+		record := &ImageRecord{Services: []ServiceImage{{Service: "web", Image: "nginx:latest", ID: "sha256:old"}}}
+		if err := manager.images.Save("web", record); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if err := performAction(t, manager, "rollback", "web"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(mock.RunCalls) != 2 {
+			t.Fatalf("Expected 2 calls, got %v", mock.RunCalls)
+		}
+		assertSliceEqual(t, mock.RunCalls[0], []string{"image", "tag", "sha256:old", "nginx:latest"})
+		up := mock.RunCalls[1]
+		if up[5] != "up" || !slices.Equal(up[len(up)-2:], []string{"--pull", "never"}) {
+			t.Errorf("Expected up without pulling, got %v", up)
+		}
+	})
+
+	t.Run("fails without recorded images", func(t *testing.T) {
+		manager := newManager(t, &MockDockerExecutor{}, "")
+
+		err := performAction(t, manager, "rollback", "web")
+		if err == nil || !strings.Contains(err.Error(), "no previous images") {
+			t.Errorf("Expected missing record error, got: %v", err)
+		}
+	})
+
+	t.Run("update rolls back unhealthy stack", func(t *testing.T) {
+		mock := newImageExecutor("running(2)", "sha256:new")
+		images := mock.RunQuietFunc
+		mock.RunQuietFunc = func(args []string) ([]byte, error) {
+			if slices.Contains(args, "ps") {
+				return []byte(`[{"Service":"web","Name":"web-1","Image":"nginx:latest",` +
+					`"State":"running","Health":"unhealthy"}]`), nil
+			}
+			return images(args)
+		}
+		manager := newManager(t, mock, "wait-healthy: true\nhealth-timeout: 1\n")
+
+		err := performAction(t, manager, "update", "web")
+		if err == nil || !strings.Contains(err.Error(), "rolled back") {
+			t.Fatalf("Expected rolled back error, got: %v", err)
+		}
+
+		if len(mock.RunCalls) != 4 {
+			t.Fatalf("Expected pull, up, tag and up, got %v", mock.RunCalls)
+		}
+		assertSliceEqual(t, mock.RunCalls[2], []string{"image", "tag", "sha256:old", "nginx:latest"})
+		if mock.RunCalls[3][5] != "up" {
+			t.Errorf("Expected stack to be recreated, got %v", mock.RunCalls[3])
+		}
+	})
+}
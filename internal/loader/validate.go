@@ -0,0 +1,249 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// composeFileNames are the file names docker compose looks for in a project directory.
+var composeFileNames = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// ValidationError lists every problem found by Validate.
+type ValidationError struct {
+	Issues []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%d issue(s) found:\n  - %s", len(e.Issues), strings.Join(e.Issues, "\n  - "))
+}
+
+// Validate checks the global configuration and the stacks matched by
+// selector: their configuration files are parsed strictly, they must contain
+// a compose file accepted by docker compose config, and stack names and
+// dependencies must be consistent. All issues are returned together as a
+// ValidationError.
+func (m *StackManager) Validate(ctx context.Context, selector StackSelector) error {
+	var issues []string
+	addIssue := func(format string, args ...any) {
+		issue := fmt.Sprintf(format, args...)
+		issues = append(issues, strings.ReplaceAll(issue, "\n", "\n    "))
+	}
+
+	config := &Config{}
+	for _, issue := range checkConfigFile(filepath.Join(m.baseDir, "config.yaml"), config) {
+		addIssue("config.yaml: %s", issue)
+	}
+	for _, issue := range hookIssues(append(config.Hooks.BeforeAll, config.Hooks.AfterAll...), m.baseDir) {
+		addIssue("config.yaml: %s", issue)
+	}
+	if config.Schedule.Cron != "" {
+		if _, err := ParseCron(config.Schedule.Cron); err != nil {
+			addIssue("config.yaml: %v", err)
+		}
+	}
+	if _, err := config.Schedule.WindowDuration(); err != nil {
+		addIssue("config.yaml: %v", err)
+	}
+	switch config.QueryBackend {
+	case "", QueryBackendCLI, QueryBackendAPI:
+	default:
+		addIssue("config.yaml: invalid query-backend %q (expected cli or api)", config.QueryBackend)
+	}
+	if config.LogFormat != "" && !config.LogFormat.IsValid() {
+		addIssue("config.yaml: invalid log-format %q (expected text or json)", config.LogFormat)
+	}
+	if r := config.LogRotation; r.MaxSize < 0 || r.MaxAge < 0 || r.MaxFiles < 0 {
+		addIssue("config.yaml: log-rotation max-size, max-age and max-files must not be negative")
+	}
+
+	stacks, err := m.repo.FindAll(ctx)
+	if err != nil {
+		return fmt.Errorf("discovering stacks: %w", err)
+	}
+	if err := CheckDuplicates(stacks); err != nil {
+		addIssue("%v", err)
+	}
+	for _, unknown := range UnknownDependencies(stacks) {
+		addIssue("%s", unknown)
+	}
+	if _, err := SortByDependencies(stacks); err != nil {
+		addIssue("%v", err)
+	}
+
+	if !selector.IsEmpty() {
+		if stacks, err = m.repo.Select(stacks, selector); err != nil {
+			return err
+		}
+	}
+
+	for _, stack := range stacks {
+		m.logger.Info("Validating stack: %s", stack.Name)
+		for _, issue := range m.validateStack(ctx, stack) {
+			addIssue("stack %s: %s", stack.Name, issue)
+		}
+	}
+
+	if len(issues) > 0 {
+		return &ValidationError{Issues: issues}
+	}
+
+	fmt.Fprintf(m.out, "Configuration is valid (%d stack(s) checked)\n", len(stacks))
+	return nil
+}
+
+// validateStack returns the configuration issues of a single stack.
+func (m *StackManager) validateStack(ctx context.Context, stack *Stack) []string {
+	var issues []string
+
+	stackConfig := &StackConfig{}
+	for _, issue := range checkConfigFile(filepath.Join(stack.Dir, "config.yaml"), stackConfig) {
+		issues = append(issues, "config.yaml: "+issue)
+	}
+	for _, issue := range stackConfig.mergeIssues() {
+		issues = append(issues, "config.yaml: "+issue)
+	}
+	stackConfig.CommonArgs = absEnvFileArgs(stackConfig.CommonArgs, stack.Dir)
+	stackConfig.EnvFiles = absPaths(stackConfig.EnvFiles, stack.Dir)
+	stackConfig.ComposeFiles = absPaths(stackConfig.ComposeFiles, stack.Dir)
+
+	issues = append(issues, hookIssues(stackHooks(stackConfig), stack.Dir)...)
+
+	composeFiles := m.config.MergeStackConfig(stackConfig).ComposeFiles
+	missing := false
+	for _, file := range composeFiles {
+		if !fileExists(file) {
+			issues = append(issues, fmt.Sprintf("compose file not found: %s", file))
+			missing = true
+		}
+	}
+	if len(composeFiles) == 0 && !hasComposeFile(stack.Dir) {
+		issues = append(issues, fmt.Sprintf("no compose file (expected one of %s)", strings.Join(composeFileNames, ", ")))
+		missing = true
+	}
+	if missing {
+		return issues
+	}
+
+	if err := m.compose.CheckConfig(ctx, stack, stackConfig); err != nil {
+		issues = append(issues, fmt.Sprintf("docker compose config: %v", err))
+	}
+	return issues
+}
+
+// checkConfigFile strictly parses the YAML file at path into v, if it
+// exists, and returns each problem found.
+func checkConfigFile(path string, v any) []string {
+	data, err := os.ReadFile(path) //nolint:gosec // Config path is constructed from trusted base directory
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	var issues []string
+	err = decodeYAML(data, v, true)
+	var typeErr *yaml.TypeError
+	switch {
+	case errors.As(err, &typeErr):
+		issues = typeErr.Errors
+	case err != nil:
+		return []string{err.Error()}
+	}
+
+	// Hooks decode themselves, which bypasses the known fields check
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err == nil {
+		issues = append(issues, unknownHookFields(&root)...)
+	}
+	return issues
+}
+
+// hookFields are the keys of a hook mapping.
+var hookFields = []string{"command", "script", "on-failure", "timeout"}
+
+// unknownHookFields reports unknown keys of the hook mappings below the
+// hooks key of a configuration document.
+func unknownHookFields(root *yaml.Node) []string {
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+
+	var issues []string
+	for _, lists := range mappingValues(root, "hooks") {
+		for _, list := range mappingValues(lists, "") {
+			for _, hook := range list.Content {
+				if hook.Kind != yaml.MappingNode {
+					continue
+				}
+				for i := 0; i < len(hook.Content); i += 2 {
+					key := hook.Content[i]
+					if !slices.Contains(hookFields, key.Value) {
+						issues = append(issues, fmt.Sprintf("line %d: field %s not found in type loader.Hook",
+							key.Line, key.Value))
+					}
+				}
+			}
+		}
+	}
+	return issues
+}
+
+// mappingValues returns the values of a mapping node with the given key, or
+// all values if key is empty.
+func mappingValues(node *yaml.Node, key string) []*yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var values []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if key == "" || node.Content[i].Value == key {
+			values = append(values, node.Content[i+1])
+		}
+	}
+	return values
+}
+
+// hookIssues returns the problems of hooks run from dir: hooks must define
+// either a command or an existing script. Failure policies are checked when
+// hooks are decoded.
+func hookIssues(hooks []Hook, dir string) []string {
+	var issues []string
+	for _, hook := range hooks {
+		argv, _, err := hookCommand(&hook, dir)
+		switch {
+		case err != nil:
+			issues = append(issues, err.Error())
+		case hook.Script != "" && !fileExists(argv[0]):
+			issues = append(issues, fmt.Sprintf("hook script not found: %s", argv[0]))
+		}
+	}
+	return issues
+}
+
+// stackHooks returns all lifecycle hooks of a stack.
+func stackHooks(stackConfig *StackConfig) []Hook {
+	hooks := stackConfig.Hooks
+	var all []Hook
+	for _, list := range [][]Hook{hooks.PreStart, hooks.PostStart, hooks.PreStop, hooks.PostStop} {
+		all = append(all, list...)
+	}
+	return all
+}
+
+func hasComposeFile(dir string) bool {
+	for _, name := range composeFileNames {
+		if fileExists(filepath.Join(dir, name)) {
+			return true
+		}
+	}
+	return false
+}
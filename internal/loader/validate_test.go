@@ -0,0 +1,107 @@
+package loader
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStackManagerValidate(t *testing.T) {
+	t.Run("valid configuration", func(t *testing.T) {
+		dir := t.TempDir()
+		stackDir := filepath.Join(dir, "stacks", "01-web")
+		mustMkdir(t, stackDir)
+		writeFile(t, dir, "config.yaml", "up-args: [--detach]\nhooks:\n  before-all:\n    - command: echo hi\n")
+		writeFile(t, stackDir, "compose.yaml", "services: {}\n")
+		writeFile(t, stackDir, "config.yaml", "wait-healthy: true\n")
+
+		mock := &MockDockerExecutor{}
+		manager, out := newTestManager(t, dir, nil, mock)
+
+		if err := manager.Validate(t.Context(), StackSelector{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.Contains(out.String(), "Configuration is valid (1 stack(s) checked)") {
+			t.Errorf("Expected success message, got: %s", out.String())
+		}
+		checked := slices.ContainsFunc(mock.RunQuietCalls, func(args []string) bool {
+			return slices.Contains(args, "config") && slices.Contains(args, "web")
+		})
+		if !checked {
+			t.Errorf("Expected docker compose config call, got %v", mock.RunQuietCalls)
+		}
+	})
+
+	t.Run("reports all issues", func(t *testing.T) {
+		dir := t.TempDir()
+		webDir := filepath.Join(dir, "stacks", "01-web")
+		dbDir := filepath.Join(dir, "stacks", "02-db")
+		mustMkdir(t, webDir)
+		mustMkdir(t, dbDir)
+		mustMkdir(t, filepath.Join(dir, "stacks", "03-web"))
+		writeFile(t, dir, "config.yaml",
+			"up-arg: [--detach]\nschedule:\n  cron: \"61 * * * *\"\nlog-rotation:\n  max-size: -1\nlog-format: xml\n")
+		writeFile(t, webDir, "compose.yaml", "services: {}\n")
+		writeFile(t, webDir, "config.yaml", "depends-on: [nope]\n")
+		writeFile(t, dbDir, "config.yaml", `wait-healthy: true
+hooks:
+  pre-start:
+    - script: missing.sh
+    - command: echo hi
+      timout: 5
+    - command: echo bye
+      on-failure: ignore
+`)
+
+		mock := &MockDockerExecutor{
+			RunQuietFunc: func(_ []string) ([]byte, error) {
+				return nil, errors.New("service web has neither an image nor a build context")
+			},
+		}
+		manager, _ := newTestManager(t, dir, nil, mock)
+
+		err := manager.Validate(t.Context(), StackSelector{})
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("Expected ValidationError, got: %v", err)
+		}
+
+		want := []string{
+			"config.yaml: line 1: field up-arg not found",
+			"config.yaml: invalid cron",
+			"config.yaml: invalid log-format \"xml\"",
+			"config.yaml: log-rotation max-size, max-age and max-files must not be negative",
+			"duplicate stack names",
+			"stack web: docker compose config: service web has neither",
+			"stack \"web\" depends on unknown stack \"nope\"",
+			"stack db: config.yaml: line 6: field timout not found in type loader.Hook",
+			"stack db: hook script not found",
+			"stack db: config.yaml: line 7: invalid hook on-failure \"ignore\" (expected abort or warn)",
+			"stack db: no compose file",
+		}
+		for _, issue := range want {
+			if !strings.Contains(err.Error(), issue) {
+				t.Errorf("Expected issue %q, got:\n%s", issue, err.Error())
+			}
+		}
+	})
+
+	t.Run("selector limits checked stacks", func(t *testing.T) {
+		dir := t.TempDir()
+		webDir := filepath.Join(dir, "stacks", "01-web")
+		mustMkdir(t, webDir)
+		mustMkdir(t, filepath.Join(dir, "stacks", "02-db"))
+		writeFile(t, webDir, "compose.yml", "services: {}\n")
+
+		manager, out := newTestManager(t, dir, nil, &MockDockerExecutor{})
+
+		if err := manager.Validate(t.Context(), StackSelector{Include: []string{"web"}}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.Contains(out.String(), "1 stack(s) checked") {
+			t.Errorf("Expected one stack checked, got: %s", out.String())
+		}
+	})
+}